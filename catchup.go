@@ -0,0 +1,279 @@
+package eventgoround
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCatchUpProgressInterval is how often catch-up progress is logged unless CatchUpConfig says otherwise
+const DefaultCatchUpProgressInterval = 10 * time.Second
+
+// CatchUpConfig throttles the replay of missed events so that a large backlog does not overwhelm
+// the services handlers talk to. The zero value replays as fast as the loop can fire events
+// Rates and progress are measured in wall-clock time, whatever Clock the loop runs on
+type CatchUpConfig struct {
+	Rate             float64       // Missed events fired per second, unlimited if 0 or less
+	MaxConcurrent    int           // Missed events whose handlers may run at once, unlimited if 0 or less
+	ProgressInterval time.Duration // How often progress is logged, DefaultCatchUpProgressInterval if 0 or less
+}
+
+// WithCatchUp throttles catch-up and sets how often its progress is logged
+func WithCatchUp(config CatchUpConfig) Option {
+	return func(el *EventLoop) {
+		el.catchUpConfig = config
+	}
+}
+
+// CatchUpStatus reports the progress of the current catch-up, or of the last one if the loop is not catching up
+type CatchUpStatus struct {
+	Active    bool          // Whether the loop is catching up
+	Since     time.Time     // When the catch-up started, zero if the loop never caught up
+	Processed int           // Missed events fired, skipped, coalesced or rescheduled so far
+	Remaining int           // Missed events taken from storage and still waiting, later ones count once the pass reaches them
+	Rate      float64       // Missed events processed per second
+	ETA       time.Duration // Estimated time until the catch-up finishes, 0 until the first event is processed
+}
+
+// catchUp tracks what a catch-up pass did with missed events
+type catchUp struct {
+	now         int64
+	latest      map[coalesceKey]Event
+	order       []coalesceKey
+	fired       int
+	skipped     int
+	coalesced   int
+	rescheduled int
+
+	started   time.Time
+	ended     time.Time     // Guarded by catchUpMu, zero while catching up
+	total     atomic.Int64  // Missed events taken from storage so far
+	processed atomic.Int64  // Missed events dealt with so far
+	nextFire  time.Time     // Earliest time the rate limit lets the next event fire
+	slots     chan struct{} // Free MaxConcurrent slots, nil if unlimited
+	lastLog   time.Time
+}
+
+// newCatchUp starts a catch-up pass for events due before now
+func newCatchUp(now int64) *catchUp {
+	return &catchUp{now: now, latest: make(map[coalesceKey]Event)}
+}
+
+// has reports whether an event with the same key as event was coalesced
+func (c *catchUp) has(event Event) bool {
+	_, ok := c.latest[coalesceKey{handler: event.Handler, partitionKey: event.PartitionKey}]
+	return ok
+}
+
+// coalesce keeps event as the one to fire for its key, returning the earlier one it replaced if any
+func (c *catchUp) coalesce(event Event) (Event, bool) {
+	key := coalesceKey{handler: event.Handler, partitionKey: event.PartitionKey}
+	replaced, ok := c.latest[key]
+	if ok {
+		c.coalesced++
+		c.processed.Add(1)
+	} else {
+		c.order = append(c.order, key)
+	}
+	c.latest[key] = event
+	return replaced, ok
+}
+
+// release returns the function freeing an event's concurrency slot once its handler is done, or nil if unlimited
+func (c *catchUp) release() func() {
+	if c.slots == nil {
+		return nil
+	}
+	return func() { c.slots <- struct{}{} }
+}
+
+// status reports the pass's progress as of now
+func (c *catchUp) status(now time.Time) CatchUpStatus {
+	processed := int(c.processed.Load())
+	s := CatchUpStatus{
+		Since:     c.started,
+		Processed: processed,
+		Remaining: max(int(c.total.Load())-processed, 0),
+	}
+	if elapsed := now.Sub(c.started); elapsed > 0 && processed > 0 {
+		s.Rate = float64(processed) / elapsed.Seconds()
+		s.ETA = time.Duration(float64(s.Remaining) / s.Rate * float64(time.Second))
+	}
+	return s
+}
+
+// CatchUpStatus returns the progress of the current catch-up, or of the last one if the loop is not catching up
+func (el *EventLoop) CatchUpStatus() CatchUpStatus {
+	el.catchUpMu.RLock()
+	c, active := el.catchUpPass, el.isCatchingUp
+	var ended time.Time
+	if c != nil {
+		ended = c.ended
+	}
+	el.catchUpMu.RUnlock()
+
+	if c == nil {
+		return CatchUpStatus{}
+	}
+	now := time.Now()
+	if !ended.IsZero() {
+		now = ended
+	}
+	status := c.status(now)
+	status.Active = active
+	return status
+}
+
+// startCatchUp starts a catch-up pass for events due before now
+// The missed events are counted as the pass takes them from storage, so starting never walks all pending events
+func (el *EventLoop) startCatchUp(now int64) *catchUp {
+	c := newCatchUp(now)
+	c.started = time.Now()
+	c.lastLog = c.started
+	if limit := el.catchUpConfig.MaxConcurrent; limit > 0 {
+		c.slots = make(chan struct{}, limit)
+		for range limit {
+			c.slots <- struct{}{}
+		}
+	}
+
+	el.catchUpMu.Lock()
+	el.catchUpPass = c
+	el.catchUpMu.Unlock()
+	return c
+}
+
+// endCatchUp records when the catch-up pass finished
+func (el *EventLoop) endCatchUp(c *catchUp) {
+	el.catchUpMu.Lock()
+	c.ended = time.Now()
+	el.catchUpMu.Unlock()
+}
+
+// fireMissed fires events during catch-up, holding each back until the throttle lets it through
+// Events cancelled or rescheduled while held back are left out. If the loop pauses or stops first,
+// the events not fired yet go back to storage. It returns the number fired
+func (el *EventLoop) fireMissed(c *catchUp, events []Event) int {
+	fired := 0
+	for i, event := range events {
+		if !el.throttle(c) {
+			el.requeue(events[i:])
+			return fired
+		}
+
+		release := c.release()
+		c.processed.Add(1)
+		if !el.takeHeld(event.ID) {
+			if release != nil {
+				release()
+			}
+			continue
+		}
+		el.fire(event, release)
+		fired++
+		el.logProgress(c)
+	}
+	return fired
+}
+
+// takeHeld takes an event held back by the catch-up throttle to fire it, reporting false if it was cancelled or rescheduled meanwhile
+func (el *EventLoop) takeHeld(id uint64) bool {
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	_, ok := el.held[id]
+	delete(el.held, id)
+	return ok
+}
+
+// throttle waits until the catch-up throttle lets the next missed event fire, reporting false if the loop pauses or stops first
+func (el *EventLoop) throttle(c *catchUp) bool {
+	if el.catchUpHalted() {
+		return false
+	}
+
+	if rate := el.catchUpConfig.Rate; rate > 0 {
+		if wait := time.Until(c.nextFire); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			if !awaitCatchUp(el, timer.C) {
+				return false
+			}
+		}
+		if now := time.Now(); c.nextFire.Before(now) {
+			c.nextFire = now
+		}
+		c.nextFire = c.nextFire.Add(time.Duration(float64(time.Second) / rate))
+	}
+
+	if c.slots != nil && !awaitCatchUp(el, c.slots) {
+		return false
+	}
+	return true
+}
+
+// awaitCatchUp receives from ch on behalf of a catch-up pass, reporting false if the loop pauses or stops first
+// Events scheduled meanwhile are taken off eventChan so it never fills up, they stay staged until the pass ends
+func awaitCatchUp[T any](el *EventLoop, ch <-chan T) bool {
+	for {
+		select {
+		case <-ch:
+			return true
+		case <-el.stopChan:
+			return false
+		case <-el.eventChan:
+		case <-el.wakeChan:
+			if el.catchUpHalted() {
+				return false
+			}
+		}
+	}
+}
+
+// catchUpHalted reports whether the loop paused or is stopping, which ends catch-up with the
+// missed events not processed yet left pending
+func (el *EventLoop) catchUpHalted() bool {
+	state := el.State()
+	return state == StatePaused || state >= StateStopping
+}
+
+// requeue puts events held back by the catch-up throttle back in storage, so that events a halted catch-up
+// did not fire stay pending. Events cancelled or rescheduled while held back are left out
+func (el *EventLoop) requeue(events []Event) {
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	records := make([]logRecord, 0, len(events))
+	for _, event := range events {
+		held, ok := el.held[event.ID]
+		if !ok {
+			continue
+		}
+		delete(el.held, held.ID)
+
+		// The held occurrence of a series carries it on again in place of the next one already queued
+		if _, _, err := el.storage.Remove(held.ID); err != nil {
+			el.logError("failed to remove next occurrence", "id", held.ID, "error", err)
+		}
+		records = append(records, logRecord{Op: logOpSchedule, ID: held.ID, Event: &held})
+		if err := el.storage.Add(held); err != nil {
+			el.logError("failed to store event", "id", held.ID, "error", err)
+		}
+	}
+	el.persist(records...)
+}
+
+// logProgress logs the pass's progress if the progress interval has passed since it was last logged
+func (el *EventLoop) logProgress(c *catchUp) {
+	interval := el.catchUpConfig.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultCatchUpProgressInterval
+	}
+	now := time.Now()
+	if now.Sub(c.lastLog) < interval {
+		return
+	}
+	c.lastLog = now
+
+	status := c.status(now)
+	el.logInfo("catch-up progress", "processed", status.Processed, "remaining", status.Remaining, "rate", status.Rate, "eta", status.ETA)
+}
@@ -0,0 +1,301 @@
+package eventgoround
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCatchUpThrottle - Scenario 27: Catch-up replays a backlog no faster than the throttle allows and reports its progress
+func TestCatchUpThrottle(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	done := tracker.track("sync", nil, 0)
+
+	var running, peak atomic.Int32
+	registry.RegisterHandler("sync", func(data any) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		running.Add(-1)
+		done(data)
+	})
+
+	loop, err := New(registry,
+		WithTickInterval(10*time.Millisecond),
+		WithCatchUp(CatchUpConfig{Rate: 40, MaxConcurrent: 2}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create event loop: %v", err)
+	}
+	defer loop.Stop()
+
+	if status := loop.CatchUpStatus(); status.Active || !status.Since.IsZero() {
+		t.Errorf("Expected an empty status before catching up, got %+v", status)
+	}
+
+	past := time.Now().Unix() - 10
+	for i := range 10 {
+		loop.ScheduleEvent(past, 0, "sync", i)
+	}
+
+	tracker.expectCount(10)
+	start := time.Now()
+	loop.Start()
+
+	// Progress is visible while the backlog is being replayed
+	deadline := time.Now().Add(time.Second)
+	for {
+		status := loop.CatchUpStatus()
+		if status.Active && status.Processed > 0 {
+			if status.Remaining == 0 || status.Processed+status.Remaining != 10 {
+				t.Errorf("Expected processed and remaining events to add up to 10, got %+v", status)
+			}
+			if status.Rate <= 0 || status.ETA <= 0 {
+				t.Errorf("Expected a rate and an ETA while catching up, got %+v", status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for catch-up progress, got %+v", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for catch-up. Got %d executions, expected 10", tracker.count())
+	}
+
+	// 10 events at 40 per second take at least 9 intervals of 25ms
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected the throttle to spread catch-up over at least 200ms, took %v", elapsed)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("Expected at most 2 handlers to run at once while catching up, saw %d", p)
+	}
+
+	deadline = time.Now().Add(time.Second)
+	for loop.IsCatchingUp() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	status := loop.CatchUpStatus()
+	if status.Active || status.Processed != 10 || status.Remaining != 0 || status.ETA != 0 {
+		t.Errorf("Expected a finished catch-up of 10 events, got %+v", status)
+	}
+
+	t.Log("Successfully throttled catch-up and reported its progress")
+}
+
+// TestCatchUpInterrupted - Scenario 28: Shutting down during a throttled catch-up keeps the missed events pending
+func TestCatchUpInterrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("sync", tracker.track("sync", nil, 0))
+
+	loop, err := New(registry,
+		WithTickInterval(10*time.Millisecond),
+		WithPersistence(path),
+		WithCatchUp(CatchUpConfig{Rate: 2}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create event loop: %v", err)
+	}
+
+	past := time.Now().Unix() - 10
+	for i := range 5 {
+		loop.ScheduleEvent(past, 0, "sync", i)
+	}
+
+	tracker.expectCount(1)
+	loop.Start()
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for the first missed event")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	summary, err := loop.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if summary.Pending != 4 {
+		t.Errorf("Expected the 4 events not yet replayed to stay pending, got %d", summary.Pending)
+	}
+	if status := loop.CatchUpStatus(); status.Processed != 1 || status.Remaining != 4 {
+		t.Errorf("Expected 1 processed and 4 remaining events, got %+v", status)
+	}
+
+	// The event log brings them back for the next catch-up
+	restored, err := New(registry, WithPersistence(path))
+	if err != nil {
+		t.Fatalf("Failed to reopen event loop: %v", err)
+	}
+	defer restored.Stop()
+	if n := restored.storage.Len(); n != 4 {
+		t.Errorf("Expected 4 pending events after restart, got %d", n)
+	}
+	if tracker.count() != 1 {
+		t.Errorf("Expected only the first missed event to run, got %d", tracker.count())
+	}
+
+	t.Log("Successfully kept missed events pending when shutting down during catch-up")
+}
+
+// TestCatchUpPause - Scenario 33: Pausing during a throttled catch-up stops the replay until the loop is unpaused
+func TestCatchUpPause(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("sync", tracker.track("sync", nil, 0))
+
+	loop, err := New(registry,
+		WithTickInterval(10*time.Millisecond),
+		WithChannelCapacity(2),
+		WithCatchUp(CatchUpConfig{Rate: 20}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create event loop: %v", err)
+	}
+	defer loop.Stop()
+
+	past := time.Now().Unix() - 10
+	for i := range 10 {
+		loop.ScheduleEvent(past, 0, "sync", i)
+	}
+
+	tracker.expectCount(2)
+	loop.Start()
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for catch-up to start")
+	}
+
+	// Scheduling while the throttled catch-up runs does not block on the small channel
+	scheduled := make(chan struct{})
+	go func() {
+		for i := range 5 {
+			loop.ScheduleEvent(time.Now().Unix()+3600, 0, "sync", i)
+		}
+		close(scheduled)
+	}()
+	select {
+	case <-scheduled:
+	case <-time.After(time.Second):
+		t.Fatal("Scheduling blocked while catching up")
+	}
+
+	// The sleeping throttle wakes up for the pause instead of firing on
+	if err := loop.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	paused := tracker.count()
+	time.Sleep(200 * time.Millisecond)
+	if count := tracker.count(); count > paused+1 {
+		t.Errorf("Expected catch-up to stop while paused, fired %d more events", count-paused)
+	}
+	status := loop.CatchUpStatus()
+	if status.Active || status.Remaining == 0 {
+		t.Errorf("Expected an interrupted catch-up with events remaining, got %+v", status)
+	}
+
+	if err := loop.Unpause(); err != nil {
+		t.Fatalf("Unpause failed: %v", err)
+	}
+	tracker.expectCount(8)
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for catch-up to resume. Got %d executions, expected 10", tracker.count())
+	}
+
+	t.Log("Successfully paused and resumed a throttled catch-up")
+}
+
+// BenchmarkCatchUpLateEvent measures a tick that finds one late event among a million pending ones
+func BenchmarkCatchUpLateEvent(b *testing.B) {
+	registry := newMockRegistry()
+	registry.RegisterHandler("late", func(any) {})
+
+	// Every benchmark event is due a day or more after the clock
+	clock := NewFakeClock(time.Unix(900_000, 0))
+	loop, err := New(registry, WithClock(clock), WithStorage(newBenchmarkStorage(b)))
+	if err != nil {
+		b.Fatalf("Failed to create event loop: %v", err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		loop.storage.Add(Event{ID: uint64(benchmarkPendingEvents + i + 1), Timestamp: 900_000 - 1, Handler: "late"})
+		loop.processTick()
+	}
+}
+
+// TestCatchUpCancelHeld - Scenario 38: Missed events held back by the throttle can still be cancelled and rescheduled
+func TestCatchUpCancelHeld(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("sync", tracker.track("sync", nil, 0))
+	registry.RegisterHandler("digest", tracker.track("digest", nil, 0))
+
+	loop, err := New(registry,
+		WithTickInterval(10*time.Millisecond),
+		WithCatchUp(CatchUpConfig{Rate: 4}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create event loop: %v", err)
+	}
+	defer loop.Stop()
+	loop.SetHandlerOptions("digest", HandlerOptions{Misfire: MisfireFireOnce})
+
+	past := time.Now().Unix() - 10
+	var handles []*EventHandle
+	for i := range 4 {
+		handle, _ := loop.ScheduleEvent(past, 0, "sync", i)
+		handles = append(handles, handle)
+	}
+	digest, _ := loop.ScheduleEvent(past, 0, "digest", nil)
+
+	tracker.expectCount(1)
+	loop.Start()
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for catch-up to start")
+	}
+
+	// The rest are waiting on the throttle, the digest until the pass ends
+	if err := loop.Reschedule(handles[1].ID(), time.Now().Unix()+3600); err != nil {
+		t.Errorf("Expected a held event to be rescheduled, got %v", err)
+	}
+	if !handles[2].Cancel() {
+		t.Error("Expected a held event to be cancelled")
+	}
+	if !digest.Cancel() {
+		t.Error("Expected a coalesced event to be cancelled")
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for loop.IsCatchingUp() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	executions := tracker.getExecutions()
+	if len(executions) != 2 || executions[0].payload != 0 || executions[1].payload != 3 {
+		t.Errorf("Expected only the first and last sync events to fire, got %v", executions)
+	}
+	if n := loop.storage.Len(); n != 1 {
+		t.Errorf("Expected the rescheduled event to be pending, got %d events", n)
+	}
+	if handles[2].Cancel() {
+		t.Error("Expected cancelling an event twice to fail")
+	}
+	if status := loop.CatchUpStatus(); status.Processed != 5 || status.Remaining != 0 {
+		t.Errorf("Expected all 5 missed events to be processed, got %+v", status)
+	}
+
+	t.Log("Successfully cancelled and rescheduled events held back by catch-up")
+}
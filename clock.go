@@ -0,0 +1,458 @@
+package eventgoround
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers to the event loop
+// It allows tests to replace wall-clock time with a FakeClock and games to run on a VirtualClock
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the Clock equivalent of time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the Clock equivalent of time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// WithClock sets the clock the event loop reads the time from
+func WithClock(clock Clock) Option {
+	return func(el *EventLoop) {
+		el.clock = clock
+	}
+}
+
+// realClock implements Clock using the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// FakeClock is a Clock whose time only moves when Advance is called
+// Timers and tickers created from it fire synchronously during Advance
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter backs both fake timers and fake tickers
+type fakeWaiter struct {
+	clock    *FakeClock
+	c        chan time.Time
+	deadline time.Time
+	period   time.Duration // Zero for timers
+	active   bool          // Waiting to fire
+	listed   bool          // Present in the clock's waiters
+}
+
+// NewFakeClock creates a fake clock starting at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	fc := &FakeClock{now: now}
+	fc.cond = sync.NewCond(&fc.mu)
+	return fc
+}
+
+// Now returns the fake current time
+func (fc *FakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.now
+}
+
+// NewTimer creates a timer that fires once the fake time has advanced by d
+func (fc *FakeClock) NewTimer(d time.Duration) Timer {
+	return fc.newWaiter(d, 0)
+}
+
+// NewTicker creates a ticker that fires every time the fake time advances by d
+func (fc *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	return fakeTicker{fc.newWaiter(d, d)}
+}
+
+// newWaiter registers a new active timer or ticker
+func (fc *FakeClock) newWaiter(d time.Duration, period time.Duration) *fakeWaiter {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	w := &fakeWaiter{
+		clock:  fc,
+		c:      make(chan time.Time, 1),
+		period: period,
+	}
+	fc.arm(w, d)
+	return w
+}
+
+// arm activates the waiter to fire after d, firing it straight away if d is not positive
+func (fc *FakeClock) arm(w *fakeWaiter, d time.Duration) {
+	if !w.listed {
+		w.listed = true
+		fc.waiters = append(fc.waiters, w)
+	}
+	w.deadline = fc.now.Add(d)
+	w.active = true
+	fc.fire(w)
+	fc.removeInactive()
+	fc.cond.Broadcast()
+}
+
+// fire sends on the waiter's channel if its deadline has passed, dropping
+// the tick if the previous one was not received yet like time.Ticker does
+func (fc *FakeClock) fire(w *fakeWaiter) {
+	if !w.active || w.deadline.After(fc.now) {
+		return
+	}
+
+	select {
+	case w.c <- fc.now:
+	default:
+	}
+
+	if w.period == 0 {
+		w.active = false
+		return
+	}
+	for !w.deadline.After(fc.now) {
+		w.deadline = w.deadline.Add(w.period)
+	}
+}
+
+// Advance moves the fake time forward, firing every timer and ticker that became due
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.now = fc.now.Add(d)
+	for _, w := range fc.waiters {
+		fc.fire(w)
+	}
+	fc.removeInactive()
+}
+
+// BlockUntil blocks until at least n timers or tickers are waiting to fire
+// Use it to make sure the event loop has armed its timer before calling Advance
+func (fc *FakeClock) BlockUntil(n int) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for fc.activeWaiters() < n {
+		fc.cond.Wait()
+	}
+}
+
+// activeWaiters counts the timers and tickers that have not fired or been stopped
+func (fc *FakeClock) activeWaiters() int {
+	return len(fc.waiters)
+}
+
+// C returns the channel the timer or ticker fires on
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+// Stop deactivates the timer or ticker, reporting whether it was active
+func (w *fakeWaiter) Stop() bool {
+	fc := w.clock
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	wasActive := w.active
+	w.active = false
+	fc.removeInactive()
+	select {
+	case <-w.c:
+	default:
+	}
+	return wasActive
+}
+
+// Reset re-arms the timer to fire after d, reporting whether it was active
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	fc := w.clock
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	wasActive := w.active
+	// Like time.Timer since Go 1.23, no stale value is received after Reset
+	select {
+	case <-w.c:
+	default:
+	}
+	fc.arm(w, d)
+	return wasActive
+}
+
+// fakeTicker adapts fakeWaiter to the Ticker interface
+type fakeTicker struct{ *fakeWaiter }
+
+// Stop turns off the ticker
+func (t fakeTicker) Stop() {
+	t.fakeWaiter.Stop()
+}
+
+// removeInactive drops stopped and fired timers so they are not kept forever
+func (fc *FakeClock) removeInactive() {
+	active := fc.waiters[:0]
+	for _, w := range fc.waiters {
+		if w.active {
+			active = append(active, w)
+		} else {
+			w.listed = false
+		}
+	}
+	for i := len(active); i < len(fc.waiters); i++ {
+		fc.waiters[i] = nil
+	}
+	fc.waiters = active
+}
+
+// VirtualClock is a Clock running game time at a multiple of wall-clock speed
+// Event timestamps are read in game time and timers wait the matching wall-clock time, so at speed 2
+// an event due in a minute of game time fires after 30 seconds. A speed of 0 freezes game time until
+// JumpTo moves it, which fires every timer and ticker it passes
+type VirtualClock struct {
+	mu       sync.Mutex
+	base     time.Time // Game time at wallBase
+	wallBase time.Time
+	speed    float64
+	waiters  map[*virtualWaiter]struct{} // Active timers and tickers
+}
+
+// virtualWaiter backs both virtual timers and virtual tickers
+type virtualWaiter struct {
+	clock    *VirtualClock
+	c        chan time.Time
+	deadline time.Time     // Game time to fire at
+	period   time.Duration // Zero for timers
+	wall     *time.Timer   // Fires once the deadline should have passed, nil while frozen
+	gen      int           // Lets wall timers that were replaced or stopped know they are stale
+}
+
+// NewVirtualClock creates a virtual clock starting at the given game time and running at speed times wall-clock time
+func NewVirtualClock(start time.Time, speed float64) *VirtualClock {
+	checkSpeed(speed)
+	return &VirtualClock{
+		base:     start,
+		wallBase: time.Now(),
+		speed:    speed,
+		waiters:  make(map[*virtualWaiter]struct{}),
+	}
+}
+
+// checkSpeed panics on speeds a virtual clock cannot run at
+func checkSpeed(speed float64) {
+	if speed < 0 || math.IsNaN(speed) || math.IsInf(speed, 0) {
+		panic("negative or non-finite speed for VirtualClock")
+	}
+}
+
+// Now returns the current game time
+func (vc *VirtualClock) Now() time.Time {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	return vc.now()
+}
+
+// now returns the current game time, the caller must hold mu
+func (vc *VirtualClock) now() time.Time {
+	return vc.base.Add(time.Duration(float64(time.Since(vc.wallBase)) * vc.speed))
+}
+
+// Speed returns how many times faster than wall-clock time game time runs
+func (vc *VirtualClock) Speed() float64 {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	return vc.speed
+}
+
+// SetSpeed changes how fast game time runs from now on, re-arming every timer and ticker
+func (vc *VirtualClock) SetSpeed(speed float64) {
+	checkSpeed(speed)
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.rebase(vc.now())
+	vc.speed = speed
+	vc.rearm()
+}
+
+// JumpTo moves game time to t, firing the timers and tickers whose deadlines it passes
+// Jumping back in time delays timers and tickers instead
+func (vc *VirtualClock) JumpTo(t time.Time) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.rebase(t)
+	vc.rearm()
+}
+
+// rebase makes game time continue from now at the current wall-clock time
+func (vc *VirtualClock) rebase(now time.Time) {
+	vc.base = now
+	vc.wallBase = time.Now()
+}
+
+// rearm re-evaluates every active timer and ticker after game time jumped or changed speed
+func (vc *VirtualClock) rearm() {
+	for w := range vc.waiters {
+		vc.arm(w)
+	}
+}
+
+// NewTimer creates a timer that fires once d of game time has passed
+func (vc *VirtualClock) NewTimer(d time.Duration) Timer {
+	return vc.newWaiter(d, 0)
+}
+
+// NewTicker creates a ticker that fires every d of game time
+func (vc *VirtualClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for VirtualClock.NewTicker")
+	}
+	return virtualTicker{vc.newWaiter(d, d)}
+}
+
+// newWaiter registers a new active timer or ticker
+func (vc *VirtualClock) newWaiter(d time.Duration, period time.Duration) *virtualWaiter {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	w := &virtualWaiter{
+		clock:    vc,
+		c:        make(chan time.Time, 1),
+		deadline: vc.now().Add(d),
+		period:   period,
+	}
+	vc.waiters[w] = struct{}{}
+	vc.arm(w)
+	return w
+}
+
+// arm points the waiter's wall-clock timer at its deadline, leaving it unarmed while game time is frozen
+func (vc *VirtualClock) arm(w *virtualWaiter) {
+	vc.disarm(w)
+
+	var wait time.Duration
+	if remaining := w.deadline.Sub(vc.now()); remaining > 0 {
+		if vc.speed == 0 {
+			return
+		}
+		wait = time.Duration(float64(remaining) / vc.speed)
+	}
+	gen := w.gen
+	w.wall = time.AfterFunc(wait, func() { vc.expire(w, gen) })
+}
+
+// disarm stops the waiter's wall-clock timer, making a callback already on its way stale
+func (vc *VirtualClock) disarm(w *virtualWaiter) {
+	w.gen++
+	if w.wall != nil {
+		w.wall.Stop()
+		w.wall = nil
+	}
+}
+
+// expire fires the waiter if its deadline has passed, dropping the tick if the previous
+// one was not received yet like time.Ticker does, and re-arms tickers for the next period
+func (vc *VirtualClock) expire(w *virtualWaiter, gen int) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	if w.gen != gen {
+		return
+	}
+	now := vc.now()
+	if now.Before(w.deadline) {
+		vc.arm(w)
+		return
+	}
+
+	select {
+	case w.c <- now:
+	default:
+	}
+
+	if w.period == 0 {
+		w.wall = nil
+		delete(vc.waiters, w)
+		return
+	}
+	// Ticks missed by a jump are dropped rather than delivered in a burst
+	w.deadline = w.deadline.Add((now.Sub(w.deadline)/w.period + 1) * w.period)
+	vc.arm(w)
+}
+
+// C returns the channel the timer or ticker fires on
+func (w *virtualWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+// Stop deactivates the timer or ticker, reporting whether it was active
+func (w *virtualWaiter) Stop() bool {
+	vc := w.clock
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	_, wasActive := vc.waiters[w]
+	delete(vc.waiters, w)
+	vc.disarm(w)
+	select {
+	case <-w.c:
+	default:
+	}
+	return wasActive
+}
+
+// Reset re-arms the timer to fire after d of game time, reporting whether it was active
+func (w *virtualWaiter) Reset(d time.Duration) bool {
+	vc := w.clock
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	_, wasActive := vc.waiters[w]
+	// Like time.Timer since Go 1.23, no stale value is received after Reset
+	select {
+	case <-w.c:
+	default:
+	}
+	w.deadline = vc.now().Add(d)
+	vc.waiters[w] = struct{}{}
+	vc.arm(w)
+	return wasActive
+}
+
+// virtualTicker adapts virtualWaiter to the Ticker interface
+type virtualTicker struct{ *virtualWaiter }
+
+// Stop turns off the ticker
+func (t virtualTicker) Stop() {
+	t.virtualWaiter.Stop()
+}
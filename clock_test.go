@@ -0,0 +1,291 @@
+package eventgoround
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockTimer(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	timer := clock.NewTimer(10 * time.Second)
+	clock.Advance(9 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("Timer fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case fired := <-timer.C():
+		if !fired.Equal(start.Add(10 * time.Second)) {
+			t.Errorf("Expected timer to fire at %v, got %v", start.Add(10*time.Second), fired)
+		}
+	default:
+		t.Fatal("Timer did not fire at its deadline")
+	}
+
+	if timer.Stop() {
+		t.Error("Stopping a fired timer should report false")
+	}
+	if timer.Reset(5 * time.Second) {
+		t.Error("Resetting a fired timer should report false")
+	}
+	if !timer.Stop() {
+		t.Error("Stopping a reset timer should report true")
+	}
+	clock.Advance(time.Minute)
+	select {
+	case <-timer.C():
+		t.Error("Stopped timer fired")
+	default:
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Second)
+		select {
+		case <-ticker.C():
+		default:
+			t.Fatalf("Ticker did not fire on tick %d", i+1)
+		}
+	}
+
+	// Ticks that are not received are dropped rather than queued
+	clock.Advance(5 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Error("Expected missed ticks to be dropped")
+	default:
+	}
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+
+	done := make(chan struct{})
+	go func() {
+		clock.BlockUntil(2)
+		close(done)
+	}()
+
+	clock.NewTimer(time.Second)
+	select {
+	case <-done:
+		t.Fatal("BlockUntil returned with only one timer waiting")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clock.NewTimer(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BlockUntil did not return once two timers were waiting")
+	}
+}
+
+// TestEventLoopFakeClock - Scenario 12: Drive the event loop without real sleeps
+func TestEventLoopFakeClock(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	registry.RegisterHandler("upgrade", tracker.track("upgrade", nil, 0))
+
+	clock := NewFakeClock(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+	loop := NewEventLoop(time.Second, registry, nil, WithClock(clock))
+	loop.Start()
+	defer loop.Stop()
+
+	// Wait for the run loop to create its ticker
+	clock.BlockUntil(1)
+
+	tracker.expectCount(1)
+	if _, err := loop.ScheduleAfter(time.Hour, "upgrade", "barracks"); err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+
+	clock.Advance(59 * time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	if count := tracker.count(); count != 0 {
+		t.Fatalf("Event fired %d times before it was due", count)
+	}
+
+	clock.Advance(time.Minute)
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for event after advancing the fake clock")
+	}
+
+	t.Log("Successfully fired an hour-long timer with a fake clock")
+}
+
+// TestWakeOnDueFakeClock - Scenario 13: The due timer follows the fake clock
+func TestWakeOnDueFakeClock(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	registry.RegisterHandler("buff", tracker.track("buff", nil, 0))
+
+	clock := NewFakeClock(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+	loop := NewEventLoop(time.Second, registry, nil, WithClock(clock), WithWakeMode(WakeOnDue), WithResolution(Milliseconds))
+	loop.Start()
+	defer loop.Stop()
+
+	tracker.expectCount(1)
+	loop.ScheduleAfter(250*time.Millisecond, "buff", "expired")
+
+	// Wait for the run loop to arm its timer for the new event
+	clock.BlockUntil(1)
+	clock.Advance(250 * time.Millisecond)
+
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for event after advancing the fake clock")
+	}
+
+	t.Log("Successfully fired a due timer with a fake clock")
+}
+
+func TestVirtualClockJump(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewVirtualClock(start, 0)
+
+	time.Sleep(20 * time.Millisecond)
+	if now := clock.Now(); !now.Equal(start) {
+		t.Fatalf("Expected a frozen clock to stay at %v, got %v", start, now)
+	}
+
+	timer := clock.NewTimer(time.Hour)
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	clock.JumpTo(start.Add(30 * time.Minute))
+	select {
+	case <-timer.C():
+		t.Fatal("Timer fired before the jump reached its deadline")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The ticker fired once for the first jump, further missed ticks are dropped
+	<-ticker.C()
+	clock.JumpTo(start.Add(2 * time.Hour))
+	select {
+	case fired := <-timer.C():
+		if !fired.Equal(start.Add(2 * time.Hour)) {
+			t.Errorf("Expected timer to fire at the jump target, got %v", fired)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timer did not fire after jumping past its deadline")
+	}
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Error("Expected ticks missed by a jump to be dropped")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Jumping back delays timers
+	timer.Reset(10 * time.Second)
+	clock.JumpTo(start)
+	clock.JumpTo(start.Add(2*time.Hour - time.Second))
+	select {
+	case <-timer.C():
+		t.Error("Timer fired although game time went back")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if !timer.Stop() {
+		t.Error("Stopping a pending timer should report true")
+	}
+}
+
+func TestVirtualClockSpeed(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewVirtualClock(start, 100)
+
+	time.Sleep(50 * time.Millisecond)
+	if elapsed := clock.Now().Sub(start); elapsed < 5*time.Second || elapsed > 30*time.Second {
+		t.Errorf("Expected about 5s of game time at 100x after 50ms, got %v", elapsed)
+	}
+
+	wall := time.Now()
+	timer := clock.NewTimer(10 * time.Second)
+	select {
+	case <-timer.C():
+		if waited := time.Since(wall); waited > time.Second {
+			t.Errorf("Expected 10s of game time at 100x to take about 100ms, took %v", waited)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timer did not fire at 100x speed")
+	}
+
+	// Changing speed re-evaluates pending timers
+	clock.SetSpeed(0)
+	timer.Reset(time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("Timer fired while game time was frozen")
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.SetSpeed(1000)
+	select {
+	case <-timer.C():
+	case <-time.After(time.Second):
+		t.Fatal("Timer did not fire after game time was sped up again")
+	}
+	if speed := clock.Speed(); speed != 1000 {
+		t.Errorf("Expected speed 1000, got %v", speed)
+	}
+}
+
+// TestEventLoopVirtualClock - Scenario 30: Fast-forward weeks of game time and run timers at a multiple of wall-clock speed
+func TestEventLoopVirtualClock(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("weekly", tracker.track("weekly", nil, 0))
+	registry.RegisterHandler("raid", tracker.track("raid", nil, 0))
+
+	clock := NewVirtualClock(start, 0)
+	loop := NewEventLoop(time.Second, registry, nil, WithClock(clock), WithWakeMode(WakeOnDue))
+	loop.Start()
+	defer loop.Stop()
+
+	day := int64(24 * 60 * 60)
+	if _, err := loop.ScheduleRecurring(start.Unix()+day, 7*day, "weekly", "reset", nil); err != nil {
+		t.Fatalf("Failed to schedule recurring event: %v", err)
+	}
+
+	// Three weeks pass in an instant, firing the occurrences on days 1, 8 and 15
+	tracker.expectCount(3)
+	clock.JumpTo(start.Add(21 * 24 * time.Hour))
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for fast-forwarded events. Got %d executions, expected 3", tracker.count())
+	}
+	time.Sleep(50 * time.Millisecond)
+	if count := tracker.count(); count != 3 {
+		t.Fatalf("Expected 3 weekly occurrences in three weeks, got %d", count)
+	}
+
+	// At 600x a minute of game time takes a tenth of a second
+	clock.SetSpeed(600)
+	tracker.expectCount(1)
+	wall := time.Now()
+	if _, err := loop.ScheduleAfter(time.Minute, "raid", "dragon"); err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatal("Timeout waiting for event at 600x speed")
+	}
+	if waited := time.Since(wall); waited < 50*time.Millisecond || waited > time.Second {
+		t.Errorf("Expected a minute of game time at 600x to take about 100ms, took %v", waited)
+	}
+
+	t.Log("Successfully fast-forwarded and scaled game time with a virtual clock")
+}
@@ -0,0 +1,48 @@
+package eventgoround
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrLoopStopped is the cause of handler contexts cancelled because the event loop stopped
+var ErrLoopStopped = errors.New("event loop stopped")
+
+// ErrHandlerTimeout is the cause of handler contexts cancelled because the handler's timeout elapsed
+var ErrHandlerTimeout = errors.New("handler timed out")
+
+// eventContextKey is the context key the running event is stored under
+type eventContextKey struct{}
+
+// EventFromContext returns the event a handler context was created for
+func EventFromContext(ctx context.Context) (Event, bool) {
+	event, ok := ctx.Value(eventContextKey{}).(Event)
+	return event, ok
+}
+
+// handlerContext returns the context to run the event's handler with
+// The timeout is reported as soon as it elapses, as a hung handler may never return
+func (el *EventLoop) handlerContext(event Event, stats *handlerCounters) (context.Context, context.CancelFunc) {
+	ctx := context.WithValue(el.ctx, eventContextKey{}, event)
+
+	timeout := el.handlerOptions(event.Handler).Timeout
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, ErrHandlerTimeout)
+	timedOut := func() {
+		if context.Cause(ctx) == ErrHandlerTimeout {
+			stats.timeouts.Add(1)
+			el.logError("handler timed out", "id", event.ID, "handler", event.Handler, "timeout", timeout)
+		}
+	}
+	stop := context.AfterFunc(ctx, timedOut)
+	return ctx, func() {
+		// A handler returning on the timeout can beat the AfterFunc goroutine, count it here instead
+		if stop() {
+			timedOut()
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,114 @@
+package eventgoround
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// contextRegistry extends mockRegistry with handlers that take a context
+type contextRegistry struct {
+	*mockRegistry
+	contextHandlers map[string]func(context.Context, Event)
+}
+
+func newContextRegistry() *contextRegistry {
+	return &contextRegistry{
+		mockRegistry:    newMockRegistry(),
+		contextHandlers: make(map[string]func(context.Context, Event)),
+	}
+}
+
+func (r *contextRegistry) RegisterContextHandler(name string, handler func(context.Context, Event)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contextHandlers[name] = handler
+}
+
+func (r *contextRegistry) GetContextHandler(name string) (func(context.Context, Event), error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.contextHandlers[name]
+	if !ok {
+		return nil, fmt.Errorf("context handler not found: %s", name)
+	}
+	return handler, nil
+}
+
+// TestContextHandlers - Scenario 21: Context handlers are cancelled by their timeout and by Stop
+func TestContextHandlers(t *testing.T) {
+	registry := newContextRegistry()
+	tracker := newExecutionTracker()
+
+	var mu sync.Mutex
+	causes := make(map[string]error)
+	var seen Event
+	wait := func(name string) func(context.Context, Event) {
+		track := tracker.track(name, nil, 0)
+		return func(ctx context.Context, ev Event) {
+			if name == "slow" {
+				mu.Lock()
+				seen, _ = EventFromContext(ctx)
+				mu.Unlock()
+			}
+			<-ctx.Done()
+			mu.Lock()
+			causes[name] = context.Cause(ctx)
+			mu.Unlock()
+			track(ev.Payload)
+		}
+	}
+	registry.RegisterContextHandler("slow", wait("slow"))
+	registry.RegisterContextHandler("hung", wait("hung"))
+
+	loop := NewEventLoop(10*time.Millisecond, registry, nil, WithResolution(Milliseconds))
+	loop.SetHandlerOptions("slow", HandlerOptions{Timeout: 30 * time.Millisecond})
+	loop.Start()
+
+	tracker.expectCount(1)
+	handle, err := loop.ScheduleAfter(0, "slow", "report")
+	if err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	loop.ScheduleAfter(0, "hung", "forever")
+
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for the handler timeout")
+	}
+	mu.Lock()
+	if causes["slow"] != ErrHandlerTimeout {
+		t.Errorf("Expected the slow handler to be cancelled by its timeout, got %v", causes["slow"])
+	}
+	if seen.ID != handle.ID() || seen.Payload != "report" {
+		t.Errorf("Expected the context to carry the event, got %+v", seen)
+	}
+	mu.Unlock()
+
+	// The timeout is counted once the handler has returned
+	deadline := time.Now().Add(time.Second)
+	for loop.HandlerStats("slow").Timeouts == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := loop.HandlerStats("slow"); stats.Runs != 1 || stats.Timeouts != 1 {
+		t.Errorf("Unexpected stats for timed out handler: %+v", stats)
+	}
+
+	// The hung handler has no timeout and only returns once the loop stops
+	tracker.expectCount(1)
+	loop.Stop()
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for Stop to cancel the hung handler")
+	}
+	mu.Lock()
+	if causes["hung"] != ErrLoopStopped {
+		t.Errorf("Expected the hung handler to be cancelled by Stop, got %v", causes["hung"])
+	}
+	mu.Unlock()
+	if stats := loop.HandlerStats("hung"); stats.Timeouts != 0 {
+		t.Errorf("Expected cancelling on Stop not to count as a timeout: %+v", stats)
+	}
+
+	t.Log("Successfully cancelled context handlers on timeout and on Stop")
+}
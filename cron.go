@@ -0,0 +1,339 @@
+package eventgoround
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression
+// Each field is stored as a bit set of the values it matches
+type CronSchedule struct {
+	second  uint64
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool // Day of month was unrestricted
+	dowStar bool // Day of week was unrestricted
+	fixed   bool // Minute and hour do not start with *, so daylight saving changes are smoothed over
+}
+
+// cronField describes the allowed range and names of a cron field
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	secondField = cronField{name: "second", min: 0, max: 59}
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Day of week allows 7 as an alias for Sunday
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronMacros maps the supported @ shortcuts to their 5 field expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression
+// Supported are the standard 5 fields (minute hour day-of-month month day-of-week),
+// 6 fields with a leading seconds field and the @yearly, @monthly, @weekly, @daily and @hourly macros
+// Fields accept *, ?, lists, ranges, steps and three letter month and weekday names
+func ParseCron(expr string) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		macro, ok := cronMacros[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron macro '%s'", spec)
+		}
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron expression '%s' must have 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	cs := &CronSchedule{}
+	var err error
+	if cs.second, _, err = parseCronField(fields[0], secondField); err != nil {
+		return nil, err
+	}
+	if cs.minute, _, err = parseCronField(fields[1], minuteField); err != nil {
+		return nil, err
+	}
+	if cs.hour, _, err = parseCronField(fields[2], hourField); err != nil {
+		return nil, err
+	}
+	if cs.dom, cs.domStar, err = parseCronField(fields[3], domField); err != nil {
+		return nil, err
+	}
+	if cs.month, _, err = parseCronField(fields[4], monthField); err != nil {
+		return nil, err
+	}
+	if cs.dow, cs.dowStar, err = parseCronField(fields[5], dowField); err != nil {
+		return nil, err
+	}
+
+	// Like Vixie cron, only jobs at fixed times are moved out of skipped and repeated hours
+	cs.fixed = !strings.HasPrefix(fields[1], "*") && !strings.HasPrefix(fields[2], "*")
+
+	// Fold Sunday as 7 onto Sunday as 0
+	if cs.dow&(1<<7) != 0 {
+		cs.dow = cs.dow&^(1<<7) | 1
+	}
+
+	return cs, nil
+}
+
+// parseCronField parses a comma separated cron field into a bit set
+// and reports whether the field was unrestricted
+func parseCronField(field string, spec cronField) (uint64, bool, error) {
+	var bits uint64
+	star := field == "*" || field == "?"
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, false, fmt.Errorf("invalid step '%s' in cron %s field", stepPart, spec.name)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = spec.min, spec.max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseCronValue(lo, spec); err != nil {
+				return 0, false, err
+			}
+			if end, err = parseCronValue(hi, spec); err != nil {
+				return 0, false, err
+			}
+			if start > end {
+				return 0, false, fmt.Errorf("invalid range '%s' in cron %s field", rangePart, spec.name)
+			}
+		default:
+			var err error
+			if start, err = parseCronValue(rangePart, spec); err != nil {
+				return 0, false, err
+			}
+			// A single value with a step such as 5/15 runs to the end of the range
+			end = start
+			if hasStep {
+				end = spec.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, star, nil
+}
+
+// parseCronValue parses a single number or name within the bounds of the field
+func parseCronValue(value string, spec cronField) (int, error) {
+	if n, ok := spec.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s' in cron %s field", value, spec.name)
+	}
+	if n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("value %d out of range [%d-%d] in cron %s field", n, spec.min, spec.max, spec.name)
+	}
+	return n, nil
+}
+
+// Next returns the first time after t matching the schedule, evaluated in t's location
+// As in Vixie cron, expressions with a fixed minute and hour match only the first pass of an hour
+// repeated when daylight saving ends, and times in an hour skipped when it starts fire at the first
+// instant after the skip. Other expressions follow the wall clock, so they do not match in a skipped
+// hour and match on both passes of a repeated one. A zero time is returned if nothing matches within five years
+func (cs *CronSchedule) Next(t time.Time) time.Time {
+	for {
+		next := cs.nextWall(t)
+		if next.IsZero() || !cs.fixed {
+			return next
+		}
+		if skipped, ok := cs.skippedMatch(t, next); ok {
+			return skipped
+		}
+		if !repeatedWall(next) {
+			return next
+		}
+		t = next
+	}
+}
+
+// skippedMatch returns the end of the first daylight saving skip after after and up to before
+// that holds a time the schedule matches
+func (cs *CronSchedule) skippedMatch(after, before time.Time) (time.Time, bool) {
+	for _, end := after.ZoneBounds(); !end.IsZero() && !end.After(before); _, end = end.ZoneBounds() {
+		_, offBefore := end.Add(-time.Nanosecond).Zone()
+		_, offAfter := end.Zone()
+		if offAfter <= offBefore || !end.After(after) {
+			continue
+		}
+
+		// The skipped wall clock times, expressed in UTC so that each of them exists
+		skipStart := end.UTC().Add(time.Duration(offBefore) * time.Second)
+		for s := 0; s < offAfter-offBefore; s++ {
+			if cs.matches(skipStart.Add(time.Duration(s) * time.Second)) {
+				return end, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// repeatedWall reports whether t is on the second pass of wall clock times repeated when daylight saving ends
+func repeatedWall(t time.Time) bool {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return false
+	}
+	_, offset := t.Zone()
+	_, previous := start.Add(-time.Nanosecond).Zone()
+	return previous > offset && t.Sub(start) < time.Duration(previous-offset)*time.Second
+}
+
+// matches reports whether every field of the schedule matches t's wall clock time
+func (cs *CronSchedule) matches(t time.Time) bool {
+	return cs.second&(1<<uint(t.Second())) != 0 &&
+		cs.minute&(1<<uint(t.Minute())) != 0 &&
+		cs.hour&(1<<uint(t.Hour())) != 0 &&
+		cs.month&(1<<uint(t.Month())) != 0 &&
+		cs.dayMatches(t)
+}
+
+// nextWall returns the first time after t whose wall clock time in t's location matches the schedule
+// A zero time is returned if nothing matches within five years
+func (cs *CronSchedule) nextWall(t time.Time) time.Time {
+	loc := t.Location()
+
+	// Start at the next whole second
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+
+	// truncated records whether the lower fields have already been reset,
+	// as the first time a field is advanced everything below it starts from zero
+	truncated := false
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for cs.month&(1<<uint(t.Month())) == 0 {
+		if !truncated {
+			truncated = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !cs.dayMatches(t) {
+		if !truncated {
+			truncated = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// Midnight may not exist on daylight saving transition days
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for cs.hour&(1<<uint(t.Hour())) == 0 {
+		if !truncated {
+			truncated = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for cs.minute&(1<<uint(t.Minute())) == 0 {
+		if !truncated {
+			truncated = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for cs.second&(1<<uint(t.Second())) == 0 {
+		if !truncated {
+			truncated = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t
+}
+
+// dayMatches applies the cron day rule: when both day of month and day of week
+// are restricted a day matches if either does, otherwise both must match
+func (cs *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := cs.dom&(1<<uint(t.Day())) != 0
+	dowMatch := cs.dow&(1<<uint(t.Weekday())) != 0
+
+	if cs.domStar || cs.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
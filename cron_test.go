@@ -0,0 +1,163 @@
+package eventgoround
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@fortnightly",
+	}
+
+	for _, expr := range invalid {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("Expected '%s' to be rejected", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Wednesday 15 January 2025, 10:30:15 UTC
+	base := time.Date(2025, time.January, 15, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.January, 15, 10, 31, 0, 0, time.UTC)},
+		{"* * * * * *", time.Date(2025, time.January, 15, 10, 30, 16, 0, time.UTC)},
+		{"30 * * * * *", time.Date(2025, time.January, 15, 10, 30, 30, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 0 * * MON", time.Date(2025, time.January, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2025, time.January, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month and day of week restricted together match either
+		{"0 0 1 * FRI", time.Date(2025, time.January, 17, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("Failed to parse '%s': %v", tt.expr, err)
+			continue
+		}
+		if next := schedule.Next(base); !next.Equal(tt.expected) {
+			t.Errorf("'%s': expected %v, got %v", tt.expr, tt.expected, next)
+		}
+	}
+}
+
+func TestCronNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+
+	// Clocks jump from 02:00 to 03:00 on 9 March 2025, so 02:30 runs at 03:00 that day
+	schedule, _ := ParseCron("30 2 * * *")
+	next := schedule.Next(time.Date(2025, time.March, 8, 12, 0, 0, 0, loc))
+	expected := time.Date(2025, time.March, 9, 3, 0, 0, 0, loc)
+	if !next.Equal(expected) {
+		t.Errorf("Expected the job in the skipped hour to run when it ends, next was %v instead of %v", next, expected)
+	}
+	next = schedule.Next(next)
+	expected = time.Date(2025, time.March, 10, 2, 30, 0, 0, loc)
+	if !next.Equal(expected) {
+		t.Errorf("Expected the job to run once on the transition day, next was %v instead of %v", next, expected)
+	}
+
+	// Jobs on a wildcard hour do not run in the skipped hour
+	schedule, _ = ParseCron("30 * * * *")
+	next = schedule.Next(time.Date(2025, time.March, 9, 1, 45, 0, 0, loc))
+	expected = time.Date(2025, time.March, 9, 3, 30, 0, 0, loc)
+	if !next.Equal(expected) {
+		t.Errorf("Expected the wildcard job to skip the missing hour, next was %v instead of %v", next, expected)
+	}
+
+	// Daily midnight keeps firing at local midnight across the transition
+	schedule, _ = ParseCron("@daily")
+	next = schedule.Next(time.Date(2025, time.March, 9, 0, 0, 0, 0, loc))
+	expected = time.Date(2025, time.March, 10, 0, 0, 0, 0, loc)
+	if !next.Equal(expected) {
+		t.Errorf("Expected local midnight %v, got %v", expected, next)
+	}
+	if elapsed := next.Sub(time.Date(2025, time.March, 9, 0, 0, 0, 0, loc)); elapsed != 23*time.Hour {
+		t.Errorf("Expected the transition day to last 23 hours, got %v", elapsed)
+	}
+
+	// Clocks fall back from 02:00 to 01:00 on 2 November 2025, so 01:30 happens twice but runs once
+	schedule, _ = ParseCron("30 1 * * *")
+	first := schedule.Next(time.Date(2025, time.November, 2, 0, 0, 0, 0, loc))
+	second := schedule.Next(first)
+	if _, offset := first.Zone(); first.Hour() != 1 || offset != -4*3600 {
+		t.Errorf("Expected the first pass of 01:30 in daylight saving time, got %v", first)
+	}
+	if expected := time.Date(2025, time.November, 3, 1, 30, 0, 0, loc); !second.Equal(expected) {
+		t.Errorf("Expected the repeated hour to match once, next was %v instead of %v", second, expected)
+	}
+
+	// Jobs on a wildcard minute follow the wall clock through both passes
+	schedule, _ = ParseCron("*/30 * * * *")
+	if next := schedule.Next(first); next.Sub(first) != 30*time.Minute || next.Hour() != 1 {
+		t.Errorf("Expected the wildcard job to match the second pass of 01:00, got %v", next)
+	}
+}
+
+// TestScheduleCron - Scenario 9: Schedule a series from a cron expression
+func TestScheduleCron(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	registry.RegisterHandler("rotation", tracker.track("rotation", nil, 0))
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil)
+	loop.Start()
+	defer loop.Stop()
+
+	if _, err := loop.ScheduleCron("not a cron", "rotation", nil, nil); err == nil {
+		t.Error("Expected an invalid expression to be rejected")
+	}
+
+	// Schedule would take the caller's Timestamp and UTC instead of the first match and local time
+	if _, err := loop.Schedule(Event{Timestamp: time.Now().Unix(), Handler: "rotation", Recurrence: &Recurrence{Cron: "0 0 * * *"}}); err == nil {
+		t.Error("Expected Schedule to reject a cron recurrence")
+	}
+
+	// Every second, twice
+	tracker.expectCount(2)
+	opts := &CronOptions{RecurringOptions: RecurringOptions{MaxCount: 2}, Location: time.UTC}
+	if _, err := loop.ScheduleCron("* * * * * *", "rotation", "shop", opts); err != nil {
+		t.Fatalf("Failed to schedule cron event: %v", err)
+	}
+
+	if !tracker.waitWithTimeout(4 * time.Second) {
+		t.Fatalf("Timeout waiting for cron events. Got %d executions, expected 2", tracker.count())
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if count := tracker.count(); count != 2 {
+		t.Errorf("Expected the series to stop after 2 occurrences, got %d", count)
+	}
+
+	t.Log("Successfully scheduled events from a cron expression")
+}
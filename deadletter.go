@@ -0,0 +1,153 @@
+package eventgoround
+
+import (
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeadLetter is an event whose handler panicked or failed without being retried again
+// Dead letters are kept in memory until they are requeued or purged
+type DeadLetter struct {
+	ID            uint64    // Identifies the dead letter, events of a recurring series share their event ID
+	Event         Event     // The event as it last ran
+	Error         string    // Error returned by the handler or the recovered panic value
+	Stack         string    // Stack trace of the panic, empty if the handler returned an error
+	Panicked      bool      // Whether the handler panicked
+	Attempts      int       // Number of times the handler ran
+	FirstFailedAt time.Time // When the first run failed, only known while the failed event stayed in memory
+	FailedAt      time.Time // When the last run failed
+}
+
+// deadLetterStore holds dead letters in the order they failed
+type deadLetterStore struct {
+	mu      sync.Mutex
+	nextID  uint64
+	letters []DeadLetter
+}
+
+// add stores a dead letter and returns it with its ID assigned
+func (s *deadLetterStore) add(letter DeadLetter) DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	letter.ID = s.nextID
+	s.letters = append(s.letters, letter)
+	return letter
+}
+
+// list returns a copy of all dead letters, oldest first
+func (s *deadLetterStore) list() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letters := make([]DeadLetter, len(s.letters))
+	copy(letters, s.letters)
+	return letters
+}
+
+// take removes and returns the dead letter with the given ID
+func (s *deadLetterStore) take(id uint64) (DeadLetter, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, letter := range s.letters {
+		if letter.ID == id {
+			s.letters = slices.Delete(s.letters, i, i+1)
+			return letter, true
+		}
+	}
+	return DeadLetter{}, false
+}
+
+// restore puts back a dead letter taken earlier, keeping the letters in the order they failed
+func (s *deadLetterStore) restore(letter DeadLetter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := sort.Search(len(s.letters), func(i int) bool { return s.letters[i].ID > letter.ID })
+	s.letters = slices.Insert(s.letters, i, letter)
+}
+
+// purge removes all dead letters and returns how many there were
+func (s *deadLetterStore) purge() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := len(s.letters)
+	s.letters = nil
+	return count
+}
+
+// DeadLetters returns the events whose handlers panicked or gave up, oldest first
+func (el *EventLoop) DeadLetters() []DeadLetter {
+	return el.deadLetters.list()
+}
+
+// RequeueDeadLetter schedules the event of a dead letter to run again now and removes the dead letter
+// The event gets a new ID, starts over with no failed attempts and no longer recurs, as its series
+// carried on when it failed
+func (el *EventLoop) RequeueDeadLetter(id uint64) (*EventHandle, error) {
+	letter, ok := el.deadLetters.take(id)
+	if !ok {
+		return nil, fmt.Errorf("dead letter %d not found", id)
+	}
+
+	event := letter.Event
+	event.ID = 0
+	event.Attempt = 0
+	event.Timestamp = el.now()
+	event.Duration = 0
+	event.Recurrence = nil
+	event.handler = nil
+	event.firstFailedAt = time.Time{}
+
+	handle, err := el.schedule(event)
+	if err != nil {
+		el.deadLetters.restore(letter)
+		return nil, err
+	}
+
+	el.logInfo("dead letter requeued", "deadLetterId", id, "id", handle.ID(), "handler", event.Handler)
+	return handle, nil
+}
+
+// PurgeDeadLetters discards all dead letters and returns how many were discarded
+func (el *EventLoop) PurgeDeadLetters() int {
+	count := el.deadLetters.purge()
+	el.logInfo("dead letters purged", "count", count)
+	return count
+}
+
+// deadLetter records an event whose handler failed for good
+func (el *EventLoop) deadLetter(event Event, reason string, stack string, panicked bool) {
+	failedAt := el.clock.Now()
+	firstFailedAt := event.firstFailedAt
+	if firstFailedAt.IsZero() {
+		firstFailedAt = failedAt
+	}
+
+	letter := el.deadLetters.add(DeadLetter{
+		Event:         event,
+		Error:         reason,
+		Stack:         stack,
+		Panicked:      panicked,
+		Attempts:      event.Attempt + 1,
+		FirstFailedAt: firstFailedAt,
+		FailedAt:      failedAt,
+	})
+	el.logError("event dead-lettered", "deadLetterId", letter.ID, "id", event.ID, "handler", event.Handler, "attempts", letter.Attempts, "panicked", panicked, "error", reason)
+}
+
+// recoverHandler turns a panicking handler into a dead letter, to be deferred around handler calls
+func (el *EventLoop) recoverHandler(event Event, stats *handlerCounters) {
+	if r := recover(); r != nil {
+		stats.panics.Add(1)
+		el.logError("handler panicked", "id", event.ID, "handler", event.Handler, "panic", r)
+		el.deadLetter(event, fmt.Sprint(r), string(debug.Stack()), true)
+	}
+}
@@ -0,0 +1,94 @@
+package eventgoround
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDeadLetters - Scenario 20: Panicking and exhausted events are dead-lettered and can be requeued
+func TestDeadLetters(t *testing.T) {
+	registry := newErrorRegistry()
+	tracker := newExecutionTracker()
+
+	var healthy atomic.Bool
+	trackPanic := tracker.track("panic", nil, 0)
+	registry.RegisterHandler("panic", func(payload any) {
+		if !healthy.Load() {
+			trackPanic(payload)
+			panic("nil map in inventory")
+		}
+		trackPanic(payload)
+	})
+	trackFail := tracker.track("fail", nil, 0)
+	registry.RegisterErrorHandler("fail", func(payload any) error {
+		trackFail(payload)
+		return errors.New("timeout")
+	})
+
+	loop := NewEventLoop(10*time.Millisecond, registry, nil, WithResolution(Milliseconds))
+	loop.SetHandlerOptions("fail", HandlerOptions{Retry: &RetryPolicy{MaxAttempts: 2, InitialBackoff: 20 * time.Millisecond}})
+	loop.Start()
+	defer loop.Stop()
+
+	tracker.expectCount(3)
+	loop.ScheduleAfter(0, "panic", "sword")
+	loop.ScheduleAfter(0, "fail", "shield")
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for failing handlers. Got %d executions, expected 3", tracker.count())
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	letters := loop.DeadLetters()
+	if len(letters) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %d", len(letters))
+	}
+	byHandler := make(map[string]DeadLetter)
+	for _, letter := range letters {
+		byHandler[letter.Event.Handler] = letter
+	}
+
+	panicked := byHandler["panic"]
+	if !panicked.Panicked || panicked.Error != "nil map in inventory" || panicked.Attempts != 1 || panicked.Event.Payload != "sword" {
+		t.Errorf("Unexpected dead letter for panicking handler: %+v", panicked)
+	}
+	if !strings.Contains(panicked.Stack, "deadletter_test.go") {
+		t.Errorf("Expected the stack trace to point at the panicking handler, got %q", panicked.Stack)
+	}
+
+	failed := byHandler["fail"]
+	if failed.Panicked || failed.Error != "timeout" || failed.Attempts != 2 || failed.Stack != "" {
+		t.Errorf("Unexpected dead letter for exhausted handler: %+v", failed)
+	}
+	if gap := failed.FailedAt.Sub(failed.FirstFailedAt); gap < 19*time.Millisecond {
+		t.Errorf("Expected the last failure at least one backoff after the first, got %v", gap)
+	}
+
+	// Requeue the panicking event once the bug is fixed
+	healthy.Store(true)
+	tracker.expectCount(1)
+	handle, err := loop.RequeueDeadLetter(panicked.ID)
+	if err != nil {
+		t.Fatalf("Failed to requeue dead letter: %v", err)
+	}
+	if handle.ID() == panicked.Event.ID {
+		t.Error("Expected the requeued event to get a new ID")
+	}
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for the requeued event")
+	}
+	if _, err := loop.RequeueDeadLetter(panicked.ID); err == nil {
+		t.Error("Expected requeueing the same dead letter twice to fail")
+	}
+
+	if n := loop.PurgeDeadLetters(); n != 1 {
+		t.Errorf("Expected to purge 1 dead letter, purged %d", n)
+	}
+	if n := len(loop.DeadLetters()); n != 0 {
+		t.Errorf("Expected no dead letters after purging, got %d", n)
+	}
+
+	t.Log("Successfully dead-lettered, requeued and purged failed events")
+}
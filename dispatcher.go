@@ -1,103 +1,578 @@
 package eventgoround
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // EventLoop manages the event scheduling and execution
 type EventLoop struct {
-	storage      *eventStorage
-	eventChan    chan Event
-	stopChan     chan struct{}
-	pauseChan    chan bool
-	isCatchingUp bool
-	catchUpMu    sync.RWMutex
-	isPaused     bool
-	pauseMu      sync.RWMutex
-	registry     IEventRegistry
-	tickInterval time.Duration
-	logger       *slog.Logger
-	logWriter    *RotatingFileWriter
-	includeInfo  bool
+	storage       Storage
+	eventChan     chan Event
+	nextID        atomic.Uint64
+	staged        map[uint64]Event // Events scheduled but not yet stored, keyed by ID
+	held          map[uint64]Event // Missed events taken from storage that catch-up has not fired yet, keyed by ID
+	pendingMu     sync.Mutex       // Serialises changes to pending events across staged, held and storage
+	stopChan      chan struct{}
+	isCatchingUp  bool
+	catchUpMu     sync.RWMutex
+	catchUpDone   chan struct{} // Closed when the current catch-up finishes
+	catchUpPass   *catchUp      // The current or last catch-up pass
+	catchUpConfig CatchUpConfig
+	state         State
+	stateMu       sync.Mutex
+	subscribers   []chan StateChange
+	pauseMode     PauseMode
+	pausedAt      time.Time // Guarded by pendingMu, when the loop was last paused
+	registry      IEventRegistry
+	tickInterval  time.Duration
+	wakeMode      WakeMode
+	resolution    Resolution
+	clock         Clock
+	persistPath   string
+	poolConfig    *PoolConfig
+	pool          *workerPool
+	handlerOpts   map[string]HandlerOptions
+	handlerOptsMu sync.RWMutex
+	limiter       *concurrencyLimiter // Per-handler concurrency limits
+	partitions    *concurrencyLimiter // Serial execution per partition key
+	deadLetters   *deadLetterStore
+	stats         map[string]*handlerCounters
+	statsMu       sync.Mutex
+	ctx           context.Context // Parent of every handler context, cancelled on Stop
+	cancel        context.CancelCauseFunc
+	runDone       chan struct{}  // Closed when the run loop returns
+	handlers      sync.WaitGroup // Fired events whose handlers have not finished
+	inflight      atomic.Int64   // Number of events tracked by handlers
+	onShutdown    ShutdownPolicy
+	drainTimeout  time.Duration
+	fatal         chan struct{} // Closed once fatalErr is set
+	fatalErr      error
+	fatalOnce     sync.Once
+	eventLog      *eventLog
+	wakeChan      chan struct{} // Signals the run loop that the earliest due time may have changed
+	logger        *slog.Logger
+	logWriter     *RotatingFileWriter
+	includeInfo   bool
+	logConfig     *LogConfig
+	logMaxBytes   int64
+	logMaxBackups int
+	eventChanSize int
 }
 
 // NewEventLoop creates a new event loop with the specified tick interval
 // logConfig is optional - pass nil to disable logging
-func NewEventLoop(tickInterval time.Duration, registry IEventRegistry, logConfig *LogConfig) *EventLoop {
+// Unlike New it never fails: a log file that cannot be opened disables logging and an event log that
+// cannot be restored disables persistence
+func NewEventLoop(tickInterval time.Duration, registry IEventRegistry, logConfig *LogConfig, opts ...Option) *EventLoop {
+	el := newEventLoop(registry, append([]Option{WithTickInterval(tickInterval), WithLogConfig(logConfig)}, opts...))
+	el.open()
+	return el
+}
+
+// New creates a new event loop configured by opts
+// It returns an error if the configuration is invalid, the log file cannot be opened or the event log cannot be restored
+func New(registry IEventRegistry, opts ...Option) (*EventLoop, error) {
+	el := newEventLoop(registry, opts)
+	if err := el.validate(); err != nil {
+		el.release()
+		return nil, err
+	}
+	if err := el.open(); err != nil {
+		el.release()
+		return nil, err
+	}
+	return el, nil
+}
+
+// validate reports configuration that would otherwise only fail once the loop runs
+func (el *EventLoop) validate() error {
+	var errs []error
+	if el.registry == nil {
+		errs = append(errs, fmt.Errorf("event registry is nil"))
+	}
+	if el.wakeMode == WakeOnTick && el.tickInterval <= 0 {
+		errs = append(errs, fmt.Errorf("tick interval must be positive, got %v", el.tickInterval))
+	}
+	if el.poolConfig != nil && el.poolConfig.Workers < 0 {
+		errs = append(errs, fmt.Errorf("worker pool size must not be negative, got %d", el.poolConfig.Workers))
+	}
+	return errors.Join(errs...)
+}
+
+// newEventLoop creates an event loop with the defaults and opts applied
+func newEventLoop(registry IEventRegistry, opts []Option) *EventLoop {
 	el := &EventLoop{
-		storage:      newEventStorage(),
-		eventChan:    make(chan Event, 2000), // Buffered channel for better performance
+		storage:      NewMemoryStorage(),
+		staged:       make(map[uint64]Event),
+		held:         make(map[uint64]Event),
 		stopChan:     make(chan struct{}),
-		pauseChan:    make(chan bool),
 		isCatchingUp: false,
-		isPaused:     false,
 		registry:     registry,
-		tickInterval: tickInterval,
+		tickInterval: DefaultTickInterval,
+		wakeChan:     make(chan struct{}, 1),
+		runDone:      make(chan struct{}),
+		fatal:        make(chan struct{}),
+		drainTimeout: defaultDrainTimeout,
+		clock:        realClock{},
+		handlerOpts:  make(map[string]HandlerOptions),
+		limiter:      newConcurrencyLimiter(),
+		partitions:   newConcurrencyLimiter(),
+		deadLetters:  &deadLetterStore{},
+		stats:        make(map[string]*handlerCounters),
 	}
+	el.ctx, el.cancel = context.WithCancelCause(context.Background())
+
+	for _, opt := range opts {
+		opt(el)
+	}
+
+	if el.eventChanSize < 1 {
+		el.eventChanSize = DefaultChannelCapacity
+	}
+	el.eventChan = make(chan Event, el.eventChanSize)
+	return el
+}
+
+// open sets up logging, the worker pool and persistence, carrying on past failures so that
+// NewEventLoop can still hand out a working loop, and returns every failure joined
+func (el *EventLoop) open() error {
+	var errs []error
 
 	// Initialize logger if config is provided
-	if logConfig != nil && logConfig.Enabled {
-		if writer, err := NewRotatingFileWriter(logConfig.FilePath, DefaultMaxBytes); err == nil {
+	if el.logger == nil && el.logConfig != nil && el.logConfig.Enabled {
+		writer, err := NewRotatingFileWriterWithBackups(el.logConfig.FilePath, el.logMaxBytes, el.logMaxBackups)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
 			el.logWriter = writer
 			el.logger = slog.New(slog.NewJSONHandler(writer, nil))
-			el.includeInfo = logConfig.IncludeInfo
+			el.includeInfo = el.logConfig.IncludeInfo
 		}
 	}
 
-	return el
+	if el.poolConfig != nil {
+		el.pool = newWorkerPool(*el.poolConfig)
+	}
+
+	// Storage may already hold events from a previous run, so never hand out their IDs again
+	el.storage.Iterate(func(event Event) bool {
+		if event.ID > el.nextID.Load() {
+			el.nextID.Store(event.ID)
+		}
+		return true
+	})
+
+	if el.persistPath != "" {
+		if err := el.restore(); err != nil {
+			el.logError("persistence disabled - failed to restore event log", "path", el.persistPath, "error", err)
+			errs = append(errs, fmt.Errorf("failed to restore event log: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// restore replays the event log into storage and keeps it open for appending
+func (el *EventLoop) restore() error {
+	log, pending, maxID, err := openEventLog(el.persistPath)
+	if err != nil {
+		return err
+	}
+	el.eventLog = log
+	if maxID > el.nextID.Load() {
+		el.nextID.Store(maxID)
+	}
+
+	for _, event := range pending {
+		// The handler may be registered after the loop is created, fire looks it up again when the event is due
+		if handler, err := el.lookupHandler(event.Handler); err == nil {
+			event.handler = handler
+		} else {
+			el.logError("restored event has no handler yet", "id", event.ID, "handler", event.Handler)
+		}
+		if err := el.storage.Add(event); err != nil {
+			return fmt.Errorf("failed to store restored event %d: %w", event.ID, err)
+		}
+	}
+
+	el.logInfo("event log restored", "path", el.persistPath, "pendingEventCount", len(pending))
+	return nil
 }
 
 // Start begins the event loop processing
-func (el *EventLoop) Start() {
-	el.logInfo("event loop started", "tickInterval", el.tickInterval)
+// A loop can only be started once, later calls return a *StateError
+func (el *EventLoop) Start() error {
+	if _, err := el.transition("start", StateRunning, StateCreated); err != nil {
+		return err
+	}
+	el.logInfo("event loop started", "tickInterval", el.tickInterval, "wakeMode", el.wakeMode, "resolution", el.resolution.unit())
 	go el.run()
+	return nil
 }
 
-// Stop gracefully stops the event loop
-func (el *EventLoop) Stop() {
+// Stop stops the event loop straight away, cancelling the context of running handlers
+// Use Shutdown to wait for running handlers first. Stopping a loop that is already stopping
+// returns a *StateError
+func (el *EventLoop) Stop() error {
+	if _, err := el.transition("stop", StateStopping, StateCreated, StateRunning, StatePaused); err != nil {
+		return err
+	}
 	el.logInfo("event loop stopping")
 	close(el.stopChan)
+	el.release()
+	el.transition("stop", StateStopped, StateStopping)
+	return nil
+}
+
+// release cancels handler contexts and closes everything the loop holds open
+func (el *EventLoop) release() {
+	el.cancel(ErrLoopStopped)
+	if el.pool != nil {
+		el.pool.stop()
+	}
+	if el.eventLog != nil {
+		el.eventLog.Close()
+	}
 	if el.logWriter != nil {
 		el.logWriter.Close()
 	}
 }
 
-// ScheduleEvent schedules an event to be executed at the specified timestamp
-// This will block during catch-up mode until all past events are processed
+// ScheduleEvent schedules an event to be executed at timestamp + duration
+// Both are expressed in the loop's resolution, seconds unless set with WithResolution
+// Events scheduled during catch-up are stored once the catch-up batch has been fired, so they always
+// fire after it. Use ScheduleEventWait to wait for catch-up to finish instead
 // Events cannot be scheduled when the loop is paused
-func (el *EventLoop) ScheduleEvent(timestamp int64, duration int64, handlername string, payload any) error {
-	if el.IsPaused() {
-		el.logError("event scheduling failed - loop is paused", "handler", handlername, "timestamp", timestamp)
-		return fmt.Errorf("event loop is paused")
+// The returned handle can be used to cancel the event before it fires
+func (el *EventLoop) ScheduleEvent(timestamp int64, duration int64, handlername string, payload any) (*EventHandle, error) {
+	return el.schedule(Event{
+		Timestamp: timestamp,
+		Duration:  duration,
+		Handler:   handlername,
+		Payload:   payload,
+	})
+}
+
+// ScheduleEventWait is like ScheduleEvent but first waits for a running catch-up to finish,
+// returning the context's error if ctx ends before it does
+func (el *EventLoop) ScheduleEventWait(ctx context.Context, timestamp int64, duration int64, handlername string, payload any) (*EventHandle, error) {
+	if err := el.waitCatchUp(ctx); err != nil {
+		el.logError("event scheduling failed - gave up waiting for catch-up", "handler", handlername, "timestamp", timestamp, "error", err)
+		return nil, err
+	}
+	return el.ScheduleEvent(timestamp, duration, handlername, payload)
+}
+
+// ScheduleAt schedules an event to be executed at the given time
+// The time is truncated to the loop's resolution
+func (el *EventLoop) ScheduleAt(at time.Time, handlername string, payload any) (*EventHandle, error) {
+	return el.ScheduleEvent(el.resolution.fromTime(at), 0, handlername, payload)
+}
+
+// ScheduleAfter schedules an event to be executed once the given duration has elapsed
+// The duration is truncated to the loop's resolution
+func (el *EventLoop) ScheduleAfter(delay time.Duration, handlername string, payload any) (*EventHandle, error) {
+	return el.ScheduleEvent(el.now(), el.resolution.fromDuration(delay), handlername, payload)
+}
+
+// ScheduleRecurring schedules an event that first fires at start and then repeats every interval
+// Both are expressed in the loop's resolution
+// opts is optional - pass nil to repeat until cancelled
+// Cancelling the returned handle stops the whole series
+func (el *EventLoop) ScheduleRecurring(start int64, interval int64, handlername string, payload any, opts *RecurringOptions) (*EventHandle, error) {
+	if interval <= 0 {
+		el.logError("event scheduling failed - invalid interval", "handler", handlername, "interval", interval)
+		return nil, fmt.Errorf("recurring interval must be positive, got %d", interval)
 	}
 
-	if el.IsCatchingUp() {
-		el.logError("event scheduling failed - currently catching up", "handler", handlername, "timestamp", timestamp)
-		return fmt.Errorf("currently catching up with past events")
+	recurrence := &Recurrence{Interval: interval}
+	if opts != nil {
+		recurrence.MaxCount = opts.MaxCount
+		recurrence.Until = opts.Until
 	}
 
-	handler, err := el.registry.GetHandler(handlername)
+	return el.schedule(Event{
+		Timestamp:  start,
+		Handler:    handlername,
+		Payload:    payload,
+		Recurrence: recurrence,
+	})
+}
 
+// ScheduleCron schedules an event that fires whenever the cron expression matches
+// See ParseCron for the supported syntax
+// opts is optional - pass nil to evaluate the expression in time.Local and repeat until cancelled
+// Cancelling the returned handle stops the whole series
+func (el *EventLoop) ScheduleCron(expr string, handlername string, payload any, opts *CronOptions) (*EventHandle, error) {
+	schedule, err := ParseCron(expr)
 	if err != nil {
-		el.logError("event scheduling failed - handler not found", "handler", handlername, "timestamp", timestamp)
-		return fmt.Errorf("handler '%s' not found", handlername)
+		el.logError("event scheduling failed - invalid cron expression", "handler", handlername, "cron", expr, "error", err)
+		return nil, err
 	}
 
-	event := Event{
-		Timestamp: timestamp, // Timestamp in seconds
-		Duration:  duration,  // Duration in seconds
-		handler:   handler,
-		Handler:   handlername,
-		Payload:   payload,
+	loc := time.Local
+	recurrence := &Recurrence{Cron: expr, schedule: schedule}
+	if opts != nil {
+		if opts.Location != nil {
+			loc = opts.Location
+		}
+		recurrence.MaxCount = opts.MaxCount
+		recurrence.Until = opts.Until
+	}
+	recurrence.Location = loc.String()
+	recurrence.loc = loc
+
+	first := schedule.Next(el.resolution.toTime(el.now()).In(loc))
+	if first.IsZero() {
+		el.logError("event scheduling failed - cron expression never fires", "handler", handlername, "cron", expr)
+		return nil, fmt.Errorf("cron expression '%s' has no upcoming fire time", expr)
 	}
-	el.eventChan <- event
-	el.logInfo("event scheduled", "handler", handlername, "timestamp", timestamp, "duration", duration)
+
+	return el.schedule(Event{
+		Timestamp:  el.resolution.fromTime(first),
+		Handler:    handlername,
+		Payload:    payload,
+		Recurrence: recurrence,
+	})
+}
+
+// Schedule schedules a fully described event, for example one with a PartitionKey
+// Timestamp, Duration, Handler, Payload, PartitionKey and Recurrence are taken from event
+// while the ID is assigned by the loop
+// Cron series are scheduled with ScheduleCron, which resolves their time zone and first fire time
+func (el *EventLoop) Schedule(event Event) (*EventHandle, error) {
+	if event.Recurrence != nil {
+		recurrence := *event.Recurrence
+		if recurrence.Cron != "" {
+			el.logError("event scheduling failed - cron recurrence", "handler", event.Handler, "cron", recurrence.Cron)
+			return nil, fmt.Errorf("cron recurrences must be scheduled with ScheduleCron")
+		}
+		if recurrence.Interval <= 0 {
+			el.logError("event scheduling failed - invalid interval", "handler", event.Handler, "interval", recurrence.Interval)
+			return nil, fmt.Errorf("recurring interval must be positive, got %d", recurrence.Interval)
+		}
+		event.Recurrence = &recurrence
+	}
+
+	event.ID = 0
+	event.handler = nil
+	return el.schedule(event)
+}
+
+// schedule assigns an ID to the event and hands it over to the event loop
+func (el *EventLoop) schedule(event Event) (*EventHandle, error) {
+	if el.isStopping() {
+		el.logError("event scheduling failed - loop is stopping", "handler", event.Handler, "timestamp", event.Timestamp)
+		return nil, ErrLoopStopped
+	}
+
+	if el.IsPaused() {
+		el.logError("event scheduling failed - loop is paused", "handler", event.Handler, "timestamp", event.Timestamp)
+		return nil, fmt.Errorf("event loop is paused")
+	}
+
+	handler, err := el.lookupHandler(event.Handler)
+
+	if err != nil {
+		el.logError("event scheduling failed - handler not found", "handler", event.Handler, "timestamp", event.Timestamp)
+		return nil, fmt.Errorf("handler '%s' not found", event.Handler)
+	}
+
+	event.ID = el.nextID.Add(1)
+	event.handler = handler
+
+	el.pendingMu.Lock()
+	if err := el.persist(logRecord{Op: logOpSchedule, ID: event.ID, Event: &event}); err != nil {
+		el.pendingMu.Unlock()
+		return nil, err
+	}
+	el.staged[event.ID] = event
+	el.pendingMu.Unlock()
+
+	// Never block the caller: while the run loop is busy, for example catching up, events that do
+	// not fit in the channel stay staged until the run loop next drains it
+	select {
+	case el.eventChan <- event:
+	default:
+		el.wake()
+	}
+	el.logInfo("event scheduled", "id", event.ID, "handler", event.Handler, "timestamp", event.Timestamp, "duration", event.Duration)
+	return &EventHandle{id: event.ID, loop: el}, nil
+}
+
+// CancelEvent removes a scheduled event before it fires
+// It returns true if the event was cancelled and false if it had already fired,
+// was already cancelled or was never scheduled
+func (el *EventLoop) CancelEvent(id uint64) bool {
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	// The event may still be on its way to storage, or held back by the catch-up throttle
+	// A held occurrence of a series is cancelled along with the next one already queued
+	_, staged := el.staged[id]
+	_, held := el.held[id]
+	var event Event
+	var stored bool
+	if !staged {
+		var err error
+		if event, stored, err = el.storage.Remove(id); err != nil {
+			el.logError("event cancellation failed - storage error", "id", id, "error", err)
+			return false
+		}
+	}
+	if !staged && !held && !stored {
+		return false
+	}
+
+	// A cancel missing from the event log would bring the event back on restart, so it stays pending
+	if err := el.persist(logRecord{Op: logOpCancel, ID: id}); err != nil {
+		if stored {
+			el.restoreEvent(event)
+		}
+		return false
+	}
+	delete(el.staged, id)
+	delete(el.held, id)
+	el.logInfo("event cancelled", "id", id)
+	return true
+}
+
+// restoreEvent puts an event taken out of storage back after its change could not be written to the event log
+func (el *EventLoop) restoreEvent(event Event) {
+	if err := el.storage.Add(event); err != nil {
+		el.logError("event lost - failed to store it again", "id", event.ID, "error", err)
+	}
+}
+
+// Reschedule moves a pending event so that it fires at newTimestamp instead
+// If newTimestamp is already in the past the event is fired immediately
+func (el *EventLoop) Reschedule(id uint64, newTimestamp int64) error {
+	return el.moveEvent(id, func(int64) int64 {
+		return newTimestamp
+	})
+}
+
+// Shorten brings a pending event forward by delta
+// If the shortened time is already in the past the event is fired immediately, and a series carries on from it
+func (el *EventLoop) Shorten(id uint64, delta int64) error {
+	return el.moveEvent(id, func(due int64) int64 {
+		return due - delta
+	})
+}
+
+// moveEvent changes the fire time of a pending event to the one computed by newDue
+func (el *EventLoop) moveEvent(id uint64, newDue func(due int64) int64) error {
+	event, due, err := el.movePending(id, newDue)
+	if err != nil || !due {
+		return err
+	}
+
+	// Fired without holding pendingMu, since with a full worker pool the submit waits
+	// for a running handler, which may need the lock to schedule or cancel events
+	el.logInfo("processing events", "timestamp", event.Timestamp+event.Duration, "eventCount", 1)
+	el.fire(event, nil)
 	return nil
 }
 
+// movePending changes the fire time of a pending event, reporting true if it is already due and was
+// taken out of storage for the caller to fire
+func (el *EventLoop) movePending(id uint64, newDue func(due int64) int64) (Event, bool, error) {
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	// Events still on their way to storage are updated in place and picked up by the next tick
+	if event, ok := el.staged[id]; ok {
+		event.Duration = newDue(event.Timestamp+event.Duration) - event.Timestamp
+		if err := el.persist(logRecord{Op: logOpSchedule, ID: id, Event: &event}); err != nil {
+			return Event{}, false, err
+		}
+		el.staged[id] = event
+		el.logInfo("event rescheduled", "id", id, "due", event.Timestamp+event.Duration)
+		return Event{}, false, nil
+	}
+
+	// An event held back by the catch-up throttle moves like a stored one, and being the earlier
+	// occurrence of a series it carries the series on in place of the next one already queued
+	event, held := el.held[id]
+	var queued Event
+	var ok, hasQueued bool
+	var err error
+	if held {
+		delete(el.held, id)
+		ok = true
+		queued, hasQueued, err = el.storage.Remove(id)
+	} else {
+		event, ok, err = el.storage.Remove(id)
+	}
+	if err != nil {
+		if held {
+			el.held[id] = event
+		}
+		el.logError("event rescheduling failed - storage error", "id", id, "error", err)
+		return Event{}, false, err
+	}
+	if !ok {
+		el.logError("event rescheduling failed - event not pending", "id", id)
+		return Event{}, false, fmt.Errorf("event %d is not pending", id)
+	}
+
+	// The event stays as it was if the move cannot be written to the event log
+	original := event
+	undo := func() {
+		if !held {
+			el.restoreEvent(original)
+			return
+		}
+		el.held[id] = original
+		if hasQueued {
+			el.restoreEvent(queued)
+		}
+	}
+	due := newDue(event.Timestamp + event.Duration)
+	event.Duration = due - event.Timestamp
+
+	if due <= el.now() && el.State() == StateRunning {
+		// Queue the next occurrence of a recurring event as processTimestamp would, so the series carries on
+		records := []logRecord{{Op: logOpFire, ID: id}}
+		next, recurring := event.next(el.resolution)
+		if recurring {
+			records = append(records, logRecord{Op: logOpSchedule, ID: next.ID, Event: &next})
+		}
+		if err := el.persist(records...); err != nil {
+			undo()
+			return Event{}, false, err
+		}
+		el.logInfo("event rescheduled", "id", id, "due", due)
+		if recurring {
+			if err := el.storage.Add(next); err != nil {
+				el.logError("failed to store next occurrence", "id", next.ID, "error", err)
+			}
+			el.wake()
+		}
+		return event, true, nil
+	}
+
+	if err := el.persist(logRecord{Op: logOpSchedule, ID: id, Event: &event}); err != nil {
+		undo()
+		return Event{}, false, err
+	}
+	el.logInfo("event rescheduled", "id", id, "due", due)
+	if err := el.storage.Add(event); err != nil {
+		el.logError("event rescheduling failed - storage error", "id", id, "error", err)
+		return Event{}, false, err
+	}
+	el.wake()
+	return Event{}, false, nil
+}
+
 // IsCatchingUp returns whether the loop is currently in catch-up mode
 func (el *EventLoop) IsCatchingUp() bool {
 	el.catchUpMu.RLock()
@@ -107,122 +582,396 @@ func (el *EventLoop) IsCatchingUp() bool {
 
 // IsPaused returns whether the loop is currently paused
 func (el *EventLoop) IsPaused() bool {
-	el.pauseMu.RLock()
-	defer el.pauseMu.RUnlock()
-	return el.isPaused
+	return el.State() == StatePaused
 }
 
 // Pause pauses the event loop, preventing event scheduling and processing
-func (el *EventLoop) Pause() {
-	el.pauseMu.Lock()
-	if !el.isPaused {
-		el.setCatchingUp(true)
-		el.isPaused = true
-		el.pauseMu.Unlock()
-		el.logInfo("event loop paused")
-		el.pauseChan <- true
-	} else {
-		el.pauseMu.Unlock()
+// Only a running loop can be paused, otherwise a *StateError is returned
+func (el *EventLoop) Pause() error {
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	if _, err := el.transition("pause", StatePaused, StateRunning); err != nil {
+		return err
 	}
+	el.pausedAt = el.clock.Now()
+	el.logInfo("event loop paused", "mode", el.pauseMode)
+	el.wake()
+	return nil
 }
 
 // Unpause resumes the event loop, allowing event scheduling and processing
-func (el *EventLoop) Unpause() {
-	el.pauseMu.Lock()
-	if el.isPaused {
-		el.isPaused = false
-		el.pauseMu.Unlock()
-		el.logInfo("event loop unpaused")
-		el.pauseChan <- false
-	} else {
-		el.pauseMu.Unlock()
+// With PauseShift every pending event is first delayed by the time the loop was paused
+// Only a paused loop can be unpaused, otherwise a *StateError is returned
+func (el *EventLoop) Unpause() error {
+	// Holding pendingMu keeps the run loop from firing events before they are shifted
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	if _, err := el.transition("unpause", StateRunning, StatePaused); err != nil {
+		return err
 	}
+	paused := el.clock.Now().Sub(el.pausedAt)
+	if el.pauseMode == PauseShift {
+		shifted := el.shiftPending(paused)
+		el.logInfo("pending events shifted", "count", shifted, "pausedFor", paused)
+	}
+	el.logInfo("event loop unpaused", "pausedFor", paused)
+	el.wake()
+	return nil
 }
 
-// setCatchingUp sets the catch-up mode state
+// setCatchingUp sets the catch-up mode state, waking up everyone waiting for catch-up to end
 func (el *EventLoop) setCatchingUp(state bool) {
 	el.catchUpMu.Lock()
 	defer el.catchUpMu.Unlock()
+
+	if state && !el.isCatchingUp {
+		el.catchUpDone = make(chan struct{})
+	} else if !state && el.isCatchingUp {
+		close(el.catchUpDone)
+	}
 	el.isCatchingUp = state
 }
 
+// waitCatchUp blocks until the current catch-up, if any, has finished or ctx ends
+func (el *EventLoop) waitCatchUp(ctx context.Context) error {
+	el.catchUpMu.RLock()
+	catching, done := el.isCatchingUp, el.catchUpDone
+	el.catchUpMu.RUnlock()
+
+	if !catching {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// wake asks the run loop to re-check the earliest due time without blocking
+func (el *EventLoop) wake() {
+	select {
+	case el.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
 // run is the main event loop
 func (el *EventLoop) run() {
-	ticker := time.NewTicker(el.tickInterval)
-	defer ticker.Stop()
-	paused := false
+	defer close(el.runDone)
+
+	var wakeC <-chan time.Time
+	var timer Timer
+
+	if el.wakeMode == WakeOnDue {
+		timer = el.clock.NewTimer(time.Hour)
+		timer.Stop()
+		defer timer.Stop()
+		wakeC = timer.C()
+	} else {
+		ticker := el.clock.NewTicker(el.tickInterval)
+		defer ticker.Stop()
+		wakeC = ticker.C()
+	}
+	// rearm points the timer at the earliest pending event when sleeping until due
+	rearm := func() {
+		if timer == nil || el.IsPaused() {
+			return
+		}
+		due, ok := el.storage.NextDue()
+		if !ok {
+			timer.Stop()
+			return
+		}
+		timer.Reset(max(el.resolution.toTime(due).Sub(el.clock.Now()), 0))
+	}
 
 	for {
 		select {
 		case <-el.stopChan:
 			return
 
-		case pauseState := <-el.pauseChan:
-			paused = pauseState
-
-		case <-ticker.C:
-			if !paused {
+		case <-wakeC:
+			if !el.IsPaused() {
+				el.drainEvents()
 				el.processTick()
+				el.maybeCompact()
+				rearm()
 			}
 
 		case event := <-el.eventChan:
-			el.storage.add(event)
+			el.storeEvent(event)
+			rearm()
+
+		case <-el.wakeChan:
+			el.drainEvents()
+			rearm()
+		}
+	}
+}
+
+// now returns the current time as a timestamp in the loop's resolution
+func (el *EventLoop) now() int64 {
+	return el.resolution.fromTime(el.clock.Now())
+}
+
+// drainEvents stores every event already waiting on eventChan, and those that did not fit in it,
+// so that a tick sees all events scheduled before it, regardless of select ordering
+func (el *EventLoop) drainEvents() {
+	for {
+		select {
+		case event := <-el.eventChan:
+			el.storeEvent(event)
+		default:
+			el.storeStaged()
+			return
 		}
 	}
 }
 
+// storeStaged moves the events left staged into storage in the order they were scheduled
+// Events still on their way through eventChan are skipped by storeEvent once they arrive
+func (el *EventLoop) storeStaged() {
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	if len(el.staged) == 0 {
+		return
+	}
+	for _, id := range slices.Sorted(maps.Keys(el.staged)) {
+		if err := el.storage.Add(el.staged[id]); err != nil {
+			el.logError("failed to store event", "id", id, "error", err)
+		}
+		delete(el.staged, id)
+	}
+}
+
+// storeEvent moves an event received on eventChan into storage
+// unless it was cancelled while in flight
+func (el *EventLoop) storeEvent(event Event) {
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	// Use the staged copy as the event may have been rescheduled while in flight
+	staged, ok := el.staged[event.ID]
+	if !ok {
+		return
+	}
+	delete(el.staged, event.ID)
+	if err := el.storage.Add(staged); err != nil {
+		el.logError("failed to store event", "id", event.ID, "error", err)
+	}
+}
+
 // processTick handles the logic for each tick of the event loop
 func (el *EventLoop) processTick() {
-	currentTime := time.Now().Unix()
+	currentTime := el.now()
 
 	// Check if we need to enter catch-up mode
-	if el.storage.hasPastEvents(currentTime) {
+	if el.hasPastEvents(currentTime) {
 		el.setCatchingUp(true)
 		el.processCatchUp(currentTime)
 		el.setCatchingUp(false)
+		if el.catchUpHalted() {
+			return
+		}
 	}
 
 	// Process current time events
-	el.processTimestamp(currentTime)
+	el.processTimestamp(currentTime, nil)
 }
 
-// processCatchUp processes all past events in chronological order
+// processCatchUp processes all past events in chronological order, applying each handler's misfire policy
 func (el *EventLoop) processCatchUp(currentTime int64) {
-	timestamps := el.storage.getTimestampsUpTo(currentTime - 1) // Process only past events
-	el.logInfo("entering catch-up mode", "pastEventCount", len(timestamps), "currentTime", currentTime)
+	c := el.startCatchUp(currentTime)
+	defer el.endCatchUp(c)
+	el.logInfo("entering catch-up mode", "pendingEventCount", el.storage.Len(), "currentTime", currentTime)
+
+	// Recurring events may queue further past occurrences while catching up, so keep
+	// taking the earliest due time until only current and future events remain,
+	// or until the loop pauses or stops, in which case missed events stay pending
+	for !el.catchUpHalted() {
+		ts, ok := el.storage.NextDue()
+		if !ok || ts >= currentTime {
+			break
+		}
+		el.processTimestamp(ts, c)
+	}
+
+	// Coalesced events fire once the pass has seen every missed event
+	coalesced := make([]Event, 0, len(c.order))
+	for _, key := range c.order {
+		coalesced = append(coalesced, c.latest[key])
+	}
+	if el.catchUpHalted() {
+		el.requeue(coalesced)
+	} else {
+		c.fired += el.fireMissed(c, coalesced)
+	}
 
-	for _, ts := range timestamps {
-		el.processTimestamp(ts)
+	if el.catchUpHalted() {
+		status := c.status(time.Now())
+		el.logInfo("catch-up interrupted", "state", el.State(), "processed", status.Processed, "remaining", status.Remaining)
 	}
+	el.logInfo("exiting catch-up mode", "fired", c.fired, "skipped", c.skipped, "coalesced", c.coalesced, "rescheduled", c.rescheduled)
 
-	el.logInfo("exiting catch-up mode")
+	// Events scheduled while catching up may still be staged, have the run loop store them
+	el.wake()
 }
 
-// processTimestamp fires all events for a specific timestamp
-func (el *EventLoop) processTimestamp(timestamp int64) {
-	events := el.storage.getAndRemove(timestamp)
+// processTimestamp fires all events due at or before a specific timestamp and returns how many it fired
+// While catching up, c carries the catch-up pass that misfire policies are applied for
+func (el *EventLoop) processTimestamp(timestamp int64, c *catchUp) int {
+	el.pendingMu.Lock()
+	events, err := el.storage.PopDue(timestamp)
+	if err != nil {
+		el.pendingMu.Unlock()
+		el.logError("failed to take due events from storage", "timestamp", timestamp, "error", err)
+		el.fail(fmt.Errorf("failed to take due events from storage: %w", err))
+		return 0
+	}
+	if c != nil {
+		c.total.Add(int64(len(events)))
+	}
 
-	if len(events) == 0 {
-		return
+	// Queue the next occurrence of recurring events before firing them
+	// so that cancelling a series can never slip in between two occurrences
+	var records []logRecord
+	var due []Event
+	for _, event := range events {
+		records = append(records, logRecord{Op: logOpFire, ID: event.ID})
+
+		action := el.misfireAction(event, c)
+		if action == misfireReschedule {
+			// The moved event carries the series on, so no next occurrence is queued
+			// A negative Duration would leave it due before now, to be caught up again forever
+			moved := event
+			moved.Timestamp = c.now
+			moved.Duration = max(event.Duration, 0)
+			records = append(records, logRecord{Op: logOpSchedule, ID: moved.ID, Event: &moved})
+			if err := el.storage.Add(moved); err != nil {
+				el.logError("failed to store rescheduled event", "id", moved.ID, "error", err)
+			}
+			c.rescheduled++
+			c.processed.Add(1)
+			continue
+		}
+
+		if next, ok := event.next(el.resolution); ok {
+			records = append(records, logRecord{Op: logOpSchedule, ID: next.ID, Event: &next})
+			if err := el.storage.Add(next); err != nil {
+				el.logError("failed to store next occurrence", "id", next.ID, "error", err)
+			}
+		}
+
+		switch action {
+		case misfireSkip:
+			c.skipped++
+			c.processed.Add(1)
+		case misfireCoalesce:
+			if replaced, ok := c.coalesce(event); ok {
+				delete(el.held, replaced.ID)
+			}
+			el.held[event.ID] = event
+		default:
+			due = append(due, event)
+			// Until the catch-up throttle lets it fire the event can still be cancelled or rescheduled
+			if c != nil {
+				el.held[event.ID] = event
+			}
+		}
 	}
+	el.persist(records...)
+	el.pendingMu.Unlock()
+
+	if len(due) == 0 {
+		if c != nil {
+			el.logProgress(c)
+		}
+		return 0
+	}
+
+	el.logInfo("processing events", "timestamp", timestamp, "eventCount", len(due))
 
-	el.logInfo("processing events", "timestamp", timestamp, "eventCount", len(events))
+	// While catching up events fire as fast as the catch-up throttle allows
+	if c != nil {
+		fired := el.fireMissed(c, due)
+		c.fired += fired
+		return fired
+	}
 
 	// Fire all events for this timestamp in separate goroutines
-	for _, event := range events {
-		go el.executeHandler(event.handler, event.Payload)
+	for _, event := range due {
+		el.fire(event, nil)
 	}
+	return len(due)
+}
+
+// hasPastEvents checks if there are any events with timestamps in the past
+func (el *EventLoop) hasPastEvents(currentTime int64) bool {
+	due, ok := el.storage.NextDue()
+	return ok && due < currentTime
 }
 
-// executeHandler executes an event handler with panic recovery
-func (el *EventLoop) executeHandler(handler func(any), payload any) {
-	defer func() {
-		if r := recover(); r != nil {
-			el.logError("handler panicked", "panic", r)
+// fire hands the event's handler over for execution, looking the handler up again if the event came from a storage that could not keep it
+// done, if not nil, is called once the handler has finished or the event was dropped
+func (el *EventLoop) fire(event Event, done func()) {
+	handler := event.handler
+	if handler == nil {
+		var err error
+		if handler, err = el.lookupHandler(event.Handler); err != nil {
+			el.logError("event dropped - handler not found", "id", event.ID, "handler", event.Handler)
+			if done != nil {
+				done()
+			}
+			return
 		}
-	}()
+	}
 
-	handler(payload)
+	el.handlers.Add(1)
+	el.inflight.Add(1)
+	el.dispatch(event, func() { el.executeHandler(handler, event) }, done)
+}
+
+// PoolStats returns the worker pool metrics, or zero values if no worker pool is configured
+func (el *EventLoop) PoolStats() PoolStats {
+	if el.pool == nil {
+		return PoolStats{}
+	}
+	return el.pool.stats()
+}
+
+// executeHandler executes an event handler with panic recovery, retrying the event if the handler fails
+// Panicking events are dead-lettered straight away
+func (el *EventLoop) executeHandler(handler handlerFunc, event Event) {
+	stats := el.handlerCounters(event.Handler)
+	stats.runs.Add(1)
+
+	ctx, cancel := el.handlerContext(event, stats)
+	defer cancel()
+	defer el.recoverHandler(event, stats)
+
+	if err := handler(ctx, event); err != nil {
+		stats.failures.Add(1)
+		el.retry(event, err)
+	}
+}
+
+// persist appends records to the event log if persistence is enabled
+func (el *EventLoop) persist(records ...logRecord) error {
+	if el.eventLog == nil {
+		return nil
+	}
+
+	if err := el.eventLog.append(records...); err != nil {
+		el.logError("failed to write event log", "error", err)
+		el.fail(fmt.Errorf("failed to persist events: %w", err))
+		return err
+	}
+	return nil
 }
 
 // logInfo logs informational messages (only if IncludeInfo is enabled)
@@ -1,7 +1,13 @@
 package eventgoround
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -253,72 +259,115 @@ func TestScheduleFutureEvents(t *testing.T) {
 	t.Log("Successfully scheduled and executed future event after specified delay")
 }
 
-// TestScheduleDuringCatchUp - Scenario 4: Try to schedule during catch-up
+// TestScheduleDuringCatchUp - Scenario 4: Schedule during catch-up
 func TestScheduleDuringCatchUp(t *testing.T) {
 	registry := newMockRegistry()
 	tracker := newExecutionTracker()
 
-	registry.RegisterHandler("past", tracker.track("past", nil, 0))
+	// Past events hold up the only worker until the gate opens, which keeps the loop in catch-up
+	// as it waits for room in the pool. A single worker also runs handlers in the order they were fired
+	gate := make(chan struct{})
+	trackPast := tracker.track("past", nil, 0)
+	registry.RegisterHandler("past", func(payload any) {
+		<-gate
+		trackPast(payload)
+	})
 	registry.RegisterHandler("during_catchup", tracker.track("during_catchup", nil, 0))
+	registry.RegisterHandler("after_catchup", tracker.track("after_catchup", nil, 0))
+	registry.RegisterHandler("overflow", tracker.track("overflow", nil, 0))
 
-	loop := NewEventLoop(50*time.Millisecond, registry, nil)
-	loop.Start()
-	defer loop.Stop()
+	loop := NewEventLoop(50*time.Millisecond, registry, nil, WithWorkerPool(PoolConfig{Workers: 1}), WithChannelCapacity(10))
 
 	// Schedule multiple events in the past to trigger catch-up mode
 	now := time.Now().Unix()
-	tracker.expectCount(5) // Expecting only the 5 past events, not the one during catch-up
-
-	// Schedule many past events to ensure catch-up takes long enough
+	tracker.expectCount(7 + 25)
 	for i := 0; i < 5; i++ {
-		// Schedule events at different timestamps in the past
 		loop.ScheduleEvent(now-20, int64(i*2), "past", fmt.Sprintf("past-%d", i))
 	}
+	loop.Start()
+	defer loop.Stop()
 
-	// Give the loop time to start processing and enter catch-up mode
-	time.Sleep(100 * time.Millisecond)
+	deadline := time.Now().Add(2 * time.Second)
+	for !loop.IsCatchingUp() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timeout waiting for catch-up mode")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Scheduling no longer fails while catching up, even for an event older than the batch
+	if _, err := loop.ScheduleEvent(now-30, 0, "during_catchup", "accepted"); err != nil {
+		t.Fatalf("Expected scheduling during catch-up to succeed, got %v", err)
+	}
 
-	// Poll until we detect catch-up mode is active
-	maxAttempts := 20
-	caughtDuringCatchup := false
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		if loop.IsCatchingUp() {
-			// Try to schedule an event while definitely in catch-up mode
-			// This event should be rejected/dropped
-			loop.ScheduleEvent(now+10, 0, "during_catchup", "should-be-rejected")
-			caughtDuringCatchup = true
-			break
+	// More events than the channel holds wait for the loop without blocking their callers
+	overflow := make(chan error, 1)
+	go func() {
+		for i := range 25 {
+			if _, err := loop.ScheduleEvent(now, 0, "overflow", i); err != nil {
+				overflow <- err
+				return
+			}
 		}
-		time.Sleep(10 * time.Millisecond)
+		overflow <- nil
+	}()
+	select {
+	case err := <-overflow:
+		if err != nil {
+			t.Fatalf("Expected scheduling beyond the channel capacity to succeed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Scheduling blocked during catch-up once the event channel was full")
 	}
 
-	if !caughtDuringCatchup {
-		t.Log("Warning: Could not catch system during catch-up mode, event may execute")
+	// ScheduleEventWait gives up when its context ends first
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := loop.ScheduleEventWait(ctx, now, 0, "after_catchup", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected ScheduleEventWait to give up with the context, got %v", err)
 	}
 
-	// Wait for the past events to complete
-	if !tracker.waitWithTimeout(3 * time.Second) {
-		t.Fatalf("Timeout waiting for past events to execute")
+	waited := make(chan error, 1)
+	go func() {
+		_, err := loop.ScheduleEventWait(context.Background(), now, 0, "after_catchup", "waited")
+		waited <- err
+	}()
+	select {
+	case <-waited:
+		t.Fatal("Expected ScheduleEventWait to block during catch-up")
+	case <-time.After(50 * time.Millisecond):
 	}
 
-	// Wait a bit more to see if the rejected event somehow executes
-	time.Sleep(1500 * time.Millisecond)
+	close(gate)
+	if err := <-waited; err != nil {
+		t.Fatalf("ScheduleEventWait failed after catch-up: %v", err)
+	}
 
-	// Verify that only the 5 past events executed, not the one scheduled during catch-up
-	count := tracker.count()
-	if count != 5 {
-		t.Errorf("Expected 5 events to execute (past events only), got %d", count)
+	if !tracker.waitWithTimeout(3 * time.Second) {
+		t.Fatalf("Timeout waiting for events. Got %d executions, expected 32", tracker.count())
 	}
 
-	// Verify the "during_catchup" handler was never called
+	// The event scheduled during catch-up fires after the catch-up batch
 	executions := tracker.getExecutions()
+	for i, exec := range executions[:5] {
+		if exec.handlerName != "past" {
+			t.Errorf("Position %d: expected the catch-up batch to fire first, got '%s'", i, exec.handlerName)
+		}
+	}
+
+	// Events that did not fit in the channel still fire in the order they were scheduled
+	next := 0
 	for _, exec := range executions {
-		if exec.handlerName == "during_catchup" {
-			t.Error("Event scheduled during catch-up should have been rejected, but it executed")
+		if exec.handlerName != "overflow" {
+			continue
+		}
+		if exec.payload != next {
+			t.Errorf("Expected overflow event %d to fire next, got %v", next, exec.payload)
 		}
+		next++
 	}
 
-	t.Log("Successfully prevented event scheduling during catch-up mode")
+	t.Log("Successfully accepted event scheduling during catch-up mode")
 }
 
 // TestPanicRecovery - Scenario 5: Demonstrate panic recovery
@@ -402,3 +451,331 @@ func TestPanicRecovery(t *testing.T) {
 
 	t.Log("Successfully recovered from handler panic, system continues operating")
 }
+
+// TestCancelEvent - Scenario 6: Cancel a pending event before it fires
+func TestCancelEvent(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	registry.RegisterHandler("cancelled", tracker.track("cancelled", nil, 0))
+	registry.RegisterHandler("kept", tracker.track("kept", nil, 0))
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil)
+	loop.Start()
+	defer loop.Stop()
+
+	now := time.Now().Unix()
+	tracker.expectCount(1)
+
+	cancelled, err := loop.ScheduleEvent(now, 1, "cancelled", "should-not-fire")
+	if err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	kept, err := loop.ScheduleEvent(now, 1, "kept", "should-fire")
+	if err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+
+	if cancelled.ID() == kept.ID() {
+		t.Fatalf("Expected unique event IDs, both were %d", kept.ID())
+	}
+
+	if !cancelled.Cancel() {
+		t.Error("Expected pending event to be cancelled")
+	}
+	if cancelled.Cancel() {
+		t.Error("Cancelling an event twice should report false")
+	}
+
+	if !tracker.waitWithTimeout(3 * time.Second) {
+		t.Fatalf("Timeout waiting for remaining event to execute")
+	}
+
+	// Give the cancelled event a chance to (incorrectly) fire
+	time.Sleep(200 * time.Millisecond)
+
+	executions := tracker.getExecutions()
+	if len(executions) != 1 || executions[0].handlerName != "kept" {
+		t.Errorf("Expected only 'kept' to execute, got %v", executions)
+	}
+
+	if loop.CancelEvent(kept.ID()) {
+		t.Error("Cancelling an event that already fired should report false")
+	}
+
+	t.Log("Successfully cancelled a pending event")
+}
+
+// TestRescheduleEvent - Scenario 7: Move pending events to a different time
+func TestRescheduleEvent(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	registry.RegisterHandler("speedup", tracker.track("speedup", nil, 0))
+	registry.RegisterHandler("rescheduled", tracker.track("rescheduled", nil, 0))
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil)
+	loop.Start()
+	defer loop.Stop()
+
+	now := time.Now().Unix()
+	tracker.expectCount(2)
+
+	// Both events would normally fire in an hour
+	speedup, _ := loop.ScheduleEvent(now, 3600, "speedup", "construction")
+	rescheduled, _ := loop.ScheduleEvent(now, 3600, "rescheduled", "buff")
+
+	// Let the events reach storage
+	time.Sleep(100 * time.Millisecond)
+
+	// A speed-up item removes the whole remaining duration
+	if err := loop.Shorten(speedup.ID(), 3600); err != nil {
+		t.Fatalf("Shorten failed: %v", err)
+	}
+
+	// Move the second event one second into the future
+	if err := loop.Reschedule(rescheduled.ID(), now+1); err != nil {
+		t.Fatalf("Reschedule failed: %v", err)
+	}
+
+	if !tracker.waitWithTimeout(3 * time.Second) {
+		t.Fatalf("Timeout waiting for rescheduled events. Got %d executions, expected 2", tracker.count())
+	}
+
+	if err := loop.Reschedule(speedup.ID(), now+10); err == nil {
+		t.Error("Expected rescheduling a fired event to fail")
+	}
+
+	t.Log("Successfully rescheduled pending events")
+}
+
+// TestScheduleRecurring - Scenario 8: Recurring events with a repeat limit and cancellation
+func TestScheduleRecurring(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	series := newExecutionTracker()
+
+	registry.RegisterHandler("tick", tracker.track("tick", nil, 0))
+	registry.RegisterHandler("series", series.track("series", nil, 0))
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil)
+	loop.Start()
+	defer loop.Stop()
+
+	now := time.Now().Unix()
+
+	// Fires at now, now+1 and now+2, then stops on its own
+	tracker.expectCount(3)
+	if _, err := loop.ScheduleRecurring(now, 1, "tick", "resource-tick", &RecurringOptions{MaxCount: 3}); err != nil {
+		t.Fatalf("Failed to schedule recurring event: %v", err)
+	}
+
+	// Repeats until cancelled
+	series.expectCount(1)
+	handle, err := loop.ScheduleRecurring(now, 1, "series", "login-reward", nil)
+	if err != nil {
+		t.Fatalf("Failed to schedule recurring event: %v", err)
+	}
+
+	if !series.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for the first occurrence of the series")
+	}
+	if !handle.Cancel() {
+		t.Error("Expected cancelling a running series to succeed")
+	}
+
+	if !tracker.waitWithTimeout(4 * time.Second) {
+		t.Fatalf("Timeout waiting for recurring events. Got %d executions, expected 3", tracker.count())
+	}
+
+	// Make sure neither series fires again
+	time.Sleep(1500 * time.Millisecond)
+
+	if count := tracker.count(); count != 3 {
+		t.Errorf("Expected exactly 3 occurrences, got %d", count)
+	}
+	if count := series.count(); count != 1 {
+		t.Errorf("Expected the cancelled series to stop after 1 occurrence, got %d", count)
+	}
+
+	if _, err := loop.ScheduleRecurring(now, 0, "tick", nil, nil); err == nil {
+		t.Error("Expected a zero interval to be rejected")
+	}
+
+	t.Log("Successfully scheduled, limited and cancelled recurring events")
+}
+
+// TestShortenRecurring - Scenario 37: Shortening an occurrence of a series into the past fires it and keeps the series going
+func TestShortenRecurring(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("harvest", tracker.track("harvest", nil, 0))
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil)
+	loop.Start()
+	defer loop.Stop()
+
+	// Hourly harvest, first due in an hour
+	now := time.Now().Unix()
+	handle, err := loop.ScheduleRecurring(now+3600, 3600, "harvest", "farm", nil)
+	if err != nil {
+		t.Fatalf("Failed to schedule recurring event: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	tracker.expectCount(1)
+	if err := loop.Shorten(handle.ID(), 3600); err != nil {
+		t.Fatalf("Shorten failed: %v", err)
+	}
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for the shortened occurrence")
+	}
+
+	// The next harvest is an hour after the shortened one
+	if n := loop.storage.Len(); n != 1 {
+		t.Fatalf("Expected the next occurrence to be pending, got %d events", n)
+	}
+	if due, _ := loop.storage.NextDue(); due != now+3600 {
+		t.Errorf("Expected the next occurrence at %d, got %d", now+3600, due)
+	}
+	if !handle.Cancel() {
+		t.Error("Expected the series to still be cancellable")
+	}
+
+	t.Log("Successfully kept a series going after shortening an occurrence")
+}
+
+// TestWakeOnDue - Scenario 10: Sleep until the next event instead of polling
+func TestWakeOnDue(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	registry.RegisterHandler("later", tracker.track("later", nil, 0))
+	registry.RegisterHandler("sooner", tracker.track("sooner", nil, 0))
+
+	// The tick interval is far longer than the test, so events can only fire through the due timer
+	loop := NewEventLoop(time.Hour, registry, nil, WithWakeMode(WakeOnDue))
+	loop.Start()
+	defer loop.Stop()
+
+	now := time.Now().Unix()
+	tracker.expectCount(2)
+
+	// Arm the timer for a distant event first, then insert an earlier one
+	later, _ := loop.ScheduleEvent(now, 3600, "later", nil)
+	loop.ScheduleEvent(now, 1, "sooner", nil)
+
+	time.Sleep(100 * time.Millisecond)
+	if err := loop.Shorten(later.ID(), 3599); err != nil {
+		t.Fatalf("Shorten failed: %v", err)
+	}
+
+	if !tracker.waitWithTimeout(3 * time.Second) {
+		t.Fatalf("Timeout waiting for events. Got %d executions, expected 2", tracker.count())
+	}
+
+	t.Log("Successfully fired events without relying on the tick interval")
+}
+
+// TestMillisecondResolution - Scenario 11: Sub-second cooldowns with millisecond timestamps
+func TestMillisecondResolution(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	registry.RegisterHandler("cooldown", tracker.track("cooldown", nil, 0))
+	registry.RegisterHandler("at", tracker.track("at", nil, 0))
+
+	loop := NewEventLoop(time.Hour, registry, nil, WithWakeMode(WakeOnDue), WithResolution(Milliseconds))
+	loop.Start()
+	defer loop.Stop()
+
+	start := time.Now()
+	tracker.expectCount(2)
+
+	if _, err := loop.ScheduleAfter(250*time.Millisecond, "cooldown", "attack"); err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	if _, err := loop.ScheduleAt(start.Add(400*time.Millisecond), "at", "heal"); err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for events. Got %d executions, expected 2", tracker.count())
+	}
+
+	for _, exec := range tracker.getExecutions() {
+		elapsed := exec.actualTime.Sub(start)
+		expected := 250 * time.Millisecond
+		if exec.handlerName == "at" {
+			expected = 400 * time.Millisecond
+		}
+		if elapsed < expected-time.Millisecond || elapsed > expected+100*time.Millisecond {
+			t.Errorf("Handler %s fired after %v, expected about %v", exec.handlerName, elapsed, expected)
+		}
+	}
+
+	t.Log("Successfully fired events with millisecond precision")
+}
+
+// TestNew - Scenario 25: New applies its options and reports what NewEventLoop used to swallow
+func TestNew(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("configured", tracker.track("configured", nil, 0))
+
+	var logs bytes.Buffer
+	loop, err := New(registry,
+		WithTickInterval(10*time.Millisecond),
+		WithChannelCapacity(8),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if cap(loop.eventChan) != 8 || loop.tickInterval != 10*time.Millisecond {
+		t.Errorf("Expected options to be applied, got capacity %d and tick interval %v", cap(loop.eventChan), loop.tickInterval)
+	}
+
+	loop.Start()
+	tracker.expectCount(1)
+	loop.ScheduleEvent(time.Now().Unix(), 0, "configured", nil)
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatal("Timeout waiting for event on a loop created with New")
+	}
+	loop.Stop()
+
+	if !strings.Contains(logs.String(), "event scheduled") {
+		t.Errorf("Expected informational messages on the given logger, got %q", logs.String())
+	}
+
+	// Failures to open the log file or the event log are returned
+	dir := t.TempDir()
+	if _, err := New(registry, WithLogConfig(&LogConfig{Enabled: true, FilePath: filepath.Join(dir, "missing", "loop.log")})); err == nil {
+		t.Error("Expected New to fail when the log file cannot be opened")
+	}
+	if _, err := New(registry, WithPersistence(dir)); err == nil {
+		t.Error("Expected New to fail when the event log cannot be opened")
+	}
+	if loop := NewEventLoop(time.Second, registry, nil, WithPersistence(dir)); loop == nil {
+		t.Error("Expected NewEventLoop to carry on without persistence")
+	}
+
+	// Invalid configuration is rejected instead of failing once the loop runs
+	invalid := map[string][]Option{
+		"zero tick interval":    {WithTickInterval(0)},
+		"negative worker count": {WithWorkerPool(PoolConfig{Workers: -1})},
+	}
+	for name, opts := range invalid {
+		if _, err := New(registry, opts...); err == nil {
+			t.Errorf("Expected New to reject a %s", name)
+		}
+	}
+	if _, err := New(nil); err == nil {
+		t.Error("Expected New to reject a nil registry")
+	}
+	if _, err := New(registry, WithWakeMode(WakeOnDue), WithTickInterval(0)); err != nil {
+		t.Errorf("Expected the tick interval to be ignored when waking on due events, got %v", err)
+	}
+
+	t.Log("Successfully created an event loop with New")
+}
@@ -1,87 +1,123 @@
 package eventgoround
 
 import (
-	"sync"
+	"context"
+	"time"
 )
 
 // Event represents a scheduled event with a handler function
 type Event struct {
-	Timestamp int64       `json:"timestamp"`
-	Duration  int64       `json:"duration"`
-	Payload   interface{} `json:"payload"`
-	Handler   string      `json:"handler"`
-	handler   func(any)   `json:"-"`
+	ID           uint64      `json:"id"`
+	Timestamp    int64       `json:"timestamp"`
+	Duration     int64       `json:"duration"`
+	Payload      interface{} `json:"payload"`
+	Handler      string      `json:"handler"`
+	PartitionKey string      `json:"partitionKey,omitempty"` // Events sharing a key, such as a player ID, run one at a time
+	Recurrence   *Recurrence `json:"recurrence,omitempty"`
+	Attempt      int         `json:"attempt,omitempty"` // Number of earlier failed runs, 0 unless the event is a retry
+	handler      handlerFunc `json:"-"`
+
+	firstFailedAt time.Time // When the first run failed, reported by dead letters
 }
 
-func (e Event) Addhandler(h func(any)) {
-	e.handler = h
+// Recurrence describes how a recurring event repeats
+type Recurrence struct {
+	Interval int64  `json:"interval,omitempty"` // Time between occurrences in the loop's resolution
+	Cron     string `json:"cron,omitempty"`     // Cron expression used instead of Interval
+	Location string `json:"location,omitempty"` // Name of the time zone the cron expression is evaluated in
+	MaxCount int    `json:"maxCount,omitempty"` // Maximum number of occurrences, 0 for no limit
+	Until    int64  `json:"until,omitempty"`    // Last timestamp an occurrence may fire at, 0 for no limit
+	Count    int    `json:"count"`              // Number of occurrences fired so far
+
+	schedule *CronSchedule  // Parsed Cron, filled in lazily
+	loc      *time.Location // Loaded Location, filled in lazily
 }
 
-// EventStorage provides thread-safe storage for events organized by timestamp
-type eventStorage struct {
-	mu     sync.RWMutex
-	events map[int64][]Event // Map of timestamp to slice of events
+// RecurringOptions limits how often a recurring event repeats
+type RecurringOptions struct {
+	MaxCount int   // Stop after this many occurrences, 0 for no limit
+	Until    int64 // Do not fire after this timestamp, 0 for no limit
 }
 
-// NewEventStorage creates a new thread-safe event storage
-func newEventStorage() *eventStorage {
-	return &eventStorage{
-		events: make(map[int64][]Event),
-	}
+// CronOptions configures events scheduled with ScheduleCron
+type CronOptions struct {
+	RecurringOptions
+	Location *time.Location // Time zone the expression is evaluated in, defaults to time.Local
 }
 
-// Add adds an event to the storage for the given timestamp + duration
-func (es *eventStorage) add(event Event) {
-	es.mu.Lock()
-	defer es.mu.Unlock()
-	timestamp := event.Duration + event.Timestamp
+// nextAfter returns the timestamp of the occurrence following due
+func (r *Recurrence) nextAfter(due int64, resolution Resolution) (int64, bool) {
+	if r.Cron == "" {
+		return due + r.Interval, true
+	}
 
-	es.events[timestamp] = append(es.events[timestamp], event)
-}
+	if r.schedule == nil {
+		schedule, err := ParseCron(r.Cron)
+		if err != nil {
+			return 0, false
+		}
+		r.schedule = schedule
+	}
+	if r.loc == nil {
+		loc, err := time.LoadLocation(r.Location)
+		if err != nil {
+			return 0, false
+		}
+		r.loc = loc
+	}
 
-// GetAndRemove retrieves all events for a given timestamp and removes them from storage
-func (es *eventStorage) getAndRemove(timestamp int64) []Event {
-	es.mu.Lock()
-	defer es.mu.Unlock()
+	next := r.schedule.Next(resolution.toTime(due).In(r.loc))
+	if next.IsZero() {
+		return 0, false
+	}
+	return resolution.fromTime(next), true
+}
 
-	events := es.events[timestamp]
-	delete(es.events, timestamp)
-	return events
+func (e Event) Addhandler(h func(any)) {
+	e.handler = func(_ context.Context, event Event) error {
+		h(event.Payload)
+		return nil
+	}
 }
 
-// GetTimestampsUpTo returns all timestamps that are less than or equal to the given time, sorted
-func (es *eventStorage) getTimestampsUpTo(currentTime int64) []int64 {
-	es.mu.RLock()
-	defer es.mu.RUnlock()
+// next returns the following occurrence of a recurring event as it fires,
+// reporting false if the event does not recur or its series is finished
+func (e Event) next(resolution Resolution) (Event, bool) {
+	if e.Recurrence == nil {
+		return Event{}, false
+	}
 
-	timestamps := make([]int64, 0)
-	for ts := range es.events {
-		if ts <= currentTime {
-			timestamps = append(timestamps, ts)
-		}
+	recurrence := *e.Recurrence
+	recurrence.Count++
+	if recurrence.MaxCount > 0 && recurrence.Count >= recurrence.MaxCount {
+		return Event{}, false
 	}
 
-	// Sort timestamps to process in chronological order
-	for i := 0; i < len(timestamps)-1; i++ {
-		for j := i + 1; j < len(timestamps); j++ {
-			if timestamps[i] > timestamps[j] {
-				timestamps[i], timestamps[j] = timestamps[j], timestamps[i]
-			}
-		}
+	due := e.Timestamp + e.Duration
+	nextDue, ok := recurrence.nextAfter(due, resolution)
+	if !ok || (recurrence.Until > 0 && nextDue > recurrence.Until) {
+		return Event{}, false
 	}
 
-	return timestamps
+	e.Timestamp = due
+	e.Duration = nextDue - due
+	e.Recurrence = &recurrence
+	return e, true
 }
 
-// HasPastEvents checks if there are any events with timestamps in the past
-func (es *eventStorage) hasPastEvents(currentTime int64) bool {
-	es.mu.RLock()
-	defer es.mu.RUnlock()
+// EventHandle refers to a scheduled event and allows it to be cancelled
+type EventHandle struct {
+	id   uint64
+	loop *EventLoop
+}
 
-	for ts := range es.events {
-		if ts < currentTime {
-			return true
-		}
-	}
-	return false
+// ID returns the unique ID of the scheduled event
+func (h *EventHandle) ID() uint64 {
+	return h.id
+}
+
+// Cancel removes the event from the loop before it fires.
+// It returns false if the event had already fired or was already cancelled
+func (h *EventHandle) Cancel() bool {
+	return h.loop.CancelEvent(h.id)
 }
@@ -0,0 +1,364 @@
+package eventgoround
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HandlerOptions configures how the event loop runs a specific handler
+type HandlerOptions struct {
+	MaxConcurrency int           // Maximum number of invocations running at once, 0 for no limit
+	Retry          *RetryPolicy  // How failed events are retried, nil to never retry
+	Timeout        time.Duration // How long an invocation may run before its context is cancelled, 0 for no limit
+
+	Misfire          MisfirePolicy // What catch-up does with events that were missed
+	MisfireThreshold time.Duration // How late an event must be to count as missed, 0 for one tick interval
+}
+
+// HandlerStats reports how the invocations of a handler went
+type HandlerStats struct {
+	Runs     uint64 // Invocations started
+	Failures uint64 // Invocations that returned an error
+	Panics   uint64 // Invocations that panicked
+	Timeouts uint64 // Invocations still running when their timeout elapsed
+}
+
+// handlerCounters holds the live counters behind HandlerStats
+type handlerCounters struct {
+	runs     atomic.Uint64
+	failures atomic.Uint64
+	panics   atomic.Uint64
+	timeouts atomic.Uint64
+}
+
+// HandlerStats returns the invocation metrics of the named handler
+func (el *EventLoop) HandlerStats(name string) HandlerStats {
+	stats := el.handlerCounters(name)
+	return HandlerStats{
+		Runs:     stats.runs.Load(),
+		Failures: stats.failures.Load(),
+		Panics:   stats.panics.Load(),
+		Timeouts: stats.timeouts.Load(),
+	}
+}
+
+// handlerCounters returns the counters of the named handler, creating them on first use
+func (el *EventLoop) handlerCounters(name string) *handlerCounters {
+	el.statsMu.Lock()
+	defer el.statsMu.Unlock()
+
+	stats, ok := el.stats[name]
+	if !ok {
+		stats = &handlerCounters{}
+		el.stats[name] = stats
+	}
+	return stats
+}
+
+// RetryPolicy configures how events whose handler returned an error are retried
+// Events that fail with an error that is not retried, or on their last attempt, are dead-lettered.
+// A failed event goes back into storage with the same ID, so it can still be cancelled,
+// and its Attempt field counts the failed runs. Retries of a recurring occurrence get a
+// new ID and do not recur, since the next occurrence of the series already holds the ID
+type RetryPolicy struct {
+	MaxAttempts    int              // Total number of runs including the first, 1 or less never retries
+	InitialBackoff time.Duration    // Delay before the first retry, rounded down to the loop's resolution
+	MaxBackoff     time.Duration    // Upper bound for the delay, 0 for no bound
+	Multiplier     float64          // Factor the delay grows by with every retry, values below 1 default to 2
+	Jitter         float64          // Fraction of the delay randomly added or taken off, between 0 and 1
+	Retryable      func(error) bool // Reports whether an error is worth retrying, nil retries every error
+}
+
+// allows reports whether an event that failed with err after the given number of runs should run again
+func (p *RetryPolicy) allows(err error, attempts int) bool {
+	if p == nil || attempts >= p.MaxAttempts {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// backoff returns the delay before the given retry, counting from 1
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+	if p.MaxBackoff > 0 {
+		delay = min(delay, float64(p.MaxBackoff))
+	}
+	if delay >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
+
+// SetHandlerOptions configures how events for the named handler are run
+// Options apply to events fired after the call, including ones scheduled earlier
+func (el *EventLoop) SetHandlerOptions(name string, opts HandlerOptions) {
+	el.handlerOptsMu.Lock()
+	defer el.handlerOptsMu.Unlock()
+	el.handlerOpts[name] = opts
+}
+
+// handlerOptions returns the options configured for the named handler
+func (el *EventLoop) handlerOptions(name string) HandlerOptions {
+	el.handlerOptsMu.RLock()
+	defer el.handlerOptsMu.RUnlock()
+	return el.handlerOpts[name]
+}
+
+// handlerFunc is the form every handler is run in, whichever registry interface provided it
+type handlerFunc func(ctx context.Context, event Event) error
+
+// lookupHandler resolves a handler by name, preferring context handlers and then handlers that return an error
+func (el *EventLoop) lookupHandler(name string) (handlerFunc, error) {
+	if registry, ok := el.registry.(IContextEventRegistry); ok {
+		if handler, err := registry.GetContextHandler(name); err == nil {
+			return func(ctx context.Context, event Event) error {
+				handler(ctx, event)
+				return nil
+			}, nil
+		}
+	}
+
+	if registry, ok := el.registry.(IErrorEventRegistry); ok {
+		if handler, err := registry.GetErrorHandler(name); err == nil {
+			return func(_ context.Context, event Event) error {
+				return handler(event.Payload)
+			}, nil
+		}
+	}
+
+	handler, err := el.registry.GetHandler(name)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ context.Context, event Event) error {
+		handler(event.Payload)
+		return nil
+	}, nil
+}
+
+// retry puts a failed event back into storage if its handler's retry policy allows another attempt
+func (el *EventLoop) retry(event Event, err error) {
+	policy := el.handlerOptions(event.Handler).Retry
+	attempts := event.Attempt + 1
+	if !policy.allows(err, attempts) {
+		el.deadLetter(event, err.Error(), "", false)
+		return
+	}
+
+	backoff := policy.backoff(attempts)
+	retry := event
+	retry.Attempt = attempts
+	if retry.firstFailedAt.IsZero() {
+		retry.firstFailedAt = el.clock.Now()
+	}
+	retry.Timestamp = el.now()
+	retry.Duration = el.resolution.fromDuration(backoff)
+	if retry.Recurrence != nil {
+		retry.ID = el.nextID.Add(1)
+		retry.Recurrence = nil
+	}
+
+	el.pendingMu.Lock()
+	storeErr := el.persist(logRecord{Op: logOpSchedule, ID: retry.ID, Event: &retry})
+	if storeErr == nil {
+		storeErr = el.storage.Add(retry)
+	}
+	el.pendingMu.Unlock()
+
+	if storeErr != nil {
+		el.logError("failed to store retry", "id", retry.ID, "handler", event.Handler, "error", storeErr)
+		el.deadLetter(event, err.Error(), "", false)
+		return
+	}
+	el.wake()
+	el.logInfo("handler failed - retrying", "id", retry.ID, "handler", event.Handler, "attempts", attempts, "backoff", backoff, "error", err)
+}
+
+// task is a handler invocation for an event together with the slots it holds
+type task struct {
+	event   Event
+	run     func()
+	done    func() // Called once run has returned or the task was dropped, may be nil
+	limited bool   // Holds a slot of the handler's concurrency limit
+}
+
+// concurrencyLimiter counts running tasks per key and queues the excess
+// It limits both concurrent invocations per handler and, with a limit of 1, runs partitions serially
+type concurrencyLimiter struct {
+	mu      sync.Mutex
+	running map[string]int
+	waiting map[string][]task
+}
+
+// newConcurrencyLimiter creates an empty limiter
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{
+		running: make(map[string]int),
+		waiting: make(map[string][]task),
+	}
+}
+
+// acquire takes a slot for the key, reporting false if it is at its limit
+// in which case t is queued and handed out by a later release
+func (l *concurrencyLimiter) acquire(key string, limit int, t task) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running[key] < limit {
+		l.running[key]++
+		return true
+	}
+	l.waiting[key] = append(l.waiting[key], t)
+	return false
+}
+
+// release passes the slot on to the oldest queued task of the key and returns it,
+// or frees the slot and reports false if nothing is queued
+func (l *concurrencyLimiter) release(key string) (task, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if queue := l.waiting[key]; len(queue) > 0 {
+		t := queue[0]
+		queue[0] = task{}
+		if len(queue) == 1 {
+			delete(l.waiting, key)
+		} else {
+			l.waiting[key] = queue[1:]
+		}
+		return t, true
+	}
+
+	l.running[key]--
+	if l.running[key] <= 0 {
+		delete(l.running, key)
+	}
+	return task{}, false
+}
+
+// dispatch runs the event's handler invocation, first waiting for earlier events
+// of the same partition and then for a free slot of the handler's concurrency limit
+func (el *EventLoop) dispatch(event Event, run func(), done func()) {
+	t := task{event: event, run: run, done: done}
+
+	if key := event.PartitionKey; key != "" && !el.partitions.acquire(key, 1, t) {
+		el.logInfo("event queued - partition busy", "id", event.ID, "partitionKey", key)
+		return
+	}
+
+	if el.admit(&t) {
+		el.start(t, false)
+	}
+}
+
+// admit takes a slot of the handler's concurrency limit for t, reporting false if t was queued instead
+func (el *EventLoop) admit(t *task) bool {
+	limit := el.handlerOptions(t.event.Handler).MaxConcurrency
+	if limit <= 0 {
+		return true
+	}
+
+	t.limited = true
+	if el.limiter.acquire(t.event.Handler, limit, *t) {
+		return true
+	}
+	el.logInfo("event queued - handler at max concurrency", "id", t.event.ID, "handler", t.event.Handler, "maxConcurrency", limit)
+	return false
+}
+
+// start submits t for execution
+// Workers resubmit without blocking, since a worker waiting for room in its own pool could deadlock it
+func (el *EventLoop) start(t task, fromWorker bool) {
+	job := func() { el.runTasks(t) }
+
+	submitted := false
+	if fromWorker {
+		submitted = el.resubmit(job)
+	} else {
+		submitted = el.submit(job)
+	}
+	if submitted {
+		return
+	}
+
+	el.logError("event dropped - worker pool is full", "id", t.event.ID, "handler", t.event.Handler)
+	el.finished(t)
+	for _, next := range el.complete(t) {
+		el.start(next, fromWorker)
+	}
+}
+
+// runTasks runs t and then, on the same goroutine, the tasks its completion allows to run
+func (el *EventLoop) runTasks(t task) {
+	for {
+		t.run()
+		el.finished(t)
+
+		ready := el.complete(t)
+		if len(ready) == 0 {
+			return
+		}
+		for _, other := range ready[1:] {
+			el.start(other, true)
+		}
+		t = ready[0]
+	}
+}
+
+// finished marks the handler of a fired event as done
+func (el *EventLoop) finished(t task) {
+	if t.done != nil {
+		t.done()
+	}
+	el.inflight.Add(-1)
+	el.handlers.Done()
+}
+
+// complete frees the slots held by t and returns the queued tasks that may run now
+func (el *EventLoop) complete(t task) []task {
+	var ready []task
+
+	if t.limited {
+		if next, ok := el.limiter.release(t.event.Handler); ok {
+			ready = append(ready, next)
+		}
+	}
+
+	if key := t.event.PartitionKey; key != "" {
+		if next, ok := el.partitions.release(key); ok && el.admit(&next) {
+			ready = append(ready, next)
+		}
+	}
+
+	return ready
+}
+
+// submit runs job on the worker pool or in a new goroutine, reporting false if it was dropped
+func (el *EventLoop) submit(job func()) bool {
+	if el.pool == nil {
+		go job()
+		return true
+	}
+	return el.pool.submit(job)
+}
+
+// resubmit is like submit but spills instead of blocking when the pool queue is full
+func (el *EventLoop) resubmit(job func()) bool {
+	if el.pool == nil {
+		go job()
+		return true
+	}
+	return el.pool.submitNoWait(job)
+}
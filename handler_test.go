@@ -0,0 +1,279 @@
+package eventgoround
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// peakTracker wraps a handler and records the highest number of concurrent invocations
+type peakTracker struct {
+	running atomic.Int64
+	peak    atomic.Int64
+}
+
+func (p *peakTracker) wrap(delay time.Duration, handler func(any)) func(any) {
+	return func(payload any) {
+		current := p.running.Add(1)
+		for {
+			old := p.peak.Load()
+			if current <= old || p.peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		p.running.Add(-1)
+		handler(payload)
+	}
+}
+
+// TestHandlerMaxConcurrency - Scenario 17: Limit how many invocations of a handler run at once
+func TestHandlerMaxConcurrency(t *testing.T) {
+	registry := newMockRegistry()
+	limitedTracker := newExecutionTracker()
+	freeTracker := newExecutionTracker()
+
+	limited := &peakTracker{}
+	free := &peakTracker{}
+	registry.RegisterHandler("inventory", limited.wrap(20*time.Millisecond, limitedTracker.track("inventory", nil, 0)))
+	registry.RegisterHandler("notify", free.wrap(20*time.Millisecond, freeTracker.track("notify", nil, 0)))
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil)
+	loop.SetHandlerOptions("inventory", HandlerOptions{MaxConcurrency: 2})
+	loop.Start()
+	defer loop.Stop()
+
+	const count = 20
+	now := time.Now().Unix()
+	limitedTracker.expectCount(count)
+	freeTracker.expectCount(count)
+	for i := 0; i < count; i++ {
+		loop.ScheduleEvent(now, 0, "inventory", i)
+		loop.ScheduleEvent(now, 0, "notify", i)
+	}
+
+	if !limitedTracker.waitWithTimeout(3*time.Second) || !freeTracker.waitWithTimeout(time.Second) {
+		t.Fatalf("Timeout waiting for events. Got %d limited and %d free executions", limitedTracker.count(), freeTracker.count())
+	}
+
+	if p := limited.peak.Load(); p > 2 {
+		t.Errorf("Expected at most 2 concurrent 'inventory' handlers, saw %d", p)
+	}
+	if p := free.peak.Load(); p <= 2 {
+		t.Errorf("Expected unlimited 'notify' handlers to fan out, peak was %d", p)
+	}
+
+	t.Log("Successfully limited handler concurrency")
+}
+
+// TestPartitionKeyOrdering - Scenario 18: Events for the same player run one at a time in schedule order
+func TestPartitionKeyOrdering(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	var mu sync.Mutex
+	order := make(map[string][]int)
+	perKey := make(map[string]*peakTracker)
+	for _, key := range []string{"player-1", "player-2"} {
+		perKey[key] = &peakTracker{}
+	}
+	overall := &peakTracker{}
+
+	track := tracker.track("player", nil, 0)
+	registry.RegisterHandler("player", func(payload any) {
+		p := payload.([2]any)
+		key, seq := p[0].(string), p[1].(int)
+		perKey[key].wrap(0, func(any) {
+			overall.wrap(5*time.Millisecond, func(any) {})(nil)
+		})(nil)
+		mu.Lock()
+		order[key] = append(order[key], seq)
+		mu.Unlock()
+		track(payload)
+	})
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil)
+	loop.Start()
+	defer loop.Stop()
+
+	const perPlayer = 10
+	now := time.Now().Unix()
+	tracker.expectCount(2 * perPlayer)
+	for i := 0; i < perPlayer; i++ {
+		for _, key := range []string{"player-1", "player-2"} {
+			_, err := loop.Schedule(Event{Timestamp: now, Handler: "player", Payload: [2]any{key, i}, PartitionKey: key})
+			if err != nil {
+				t.Fatalf("Failed to schedule event: %v", err)
+			}
+		}
+	}
+
+	if !tracker.waitWithTimeout(3 * time.Second) {
+		t.Fatalf("Timeout waiting for partitioned events. Got %d executions", tracker.count())
+	}
+
+	for key, seqs := range order {
+		if p := perKey[key].peak.Load(); p != 1 {
+			t.Errorf("Expected events of %s to run one at a time, peak was %d", key, p)
+		}
+		for i, seq := range seqs {
+			if seq != i {
+				t.Errorf("Expected events of %s to run in schedule order, got %v", key, seqs)
+				break
+			}
+		}
+	}
+	if p := overall.peak.Load(); p < 2 {
+		t.Errorf("Expected different partitions to run concurrently, peak was %d", p)
+	}
+
+	t.Log("Successfully serialised events per partition key")
+}
+
+// errorRegistry extends mockRegistry with handlers that return an error
+type errorRegistry struct {
+	*mockRegistry
+	errorHandlers map[string]func(any) error
+}
+
+func newErrorRegistry() *errorRegistry {
+	return &errorRegistry{
+		mockRegistry:  newMockRegistry(),
+		errorHandlers: make(map[string]func(any) error),
+	}
+}
+
+func (r *errorRegistry) RegisterErrorHandler(name string, handler func(any) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errorHandlers[name] = handler
+}
+
+func (r *errorRegistry) GetErrorHandler(name string) (func(any) error, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.errorHandlers[name]
+	if !ok {
+		return nil, fmt.Errorf("error handler not found: %s", name)
+	}
+	return handler, nil
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want*time.Millisecond {
+			t.Errorf("Retry %d: expected backoff %v, got %v", i+1, want*time.Millisecond, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(2); got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("Expected jittered backoff between 100ms and 300ms, got %v", got)
+		}
+	}
+
+	errPermanent := errors.New("permanent")
+	policy.Retryable = func(err error) bool { return !errors.Is(err, errPermanent) }
+	if policy.allows(errPermanent, 1) || !policy.allows(errors.New("transient"), 1) || policy.allows(errors.New("transient"), 10) {
+		t.Error("Unexpected retry decision")
+	}
+	if (*RetryPolicy)(nil).allows(errors.New("transient"), 1) {
+		t.Error("Expected a nil policy to never retry")
+	}
+}
+
+// TestHandlerRetry - Scenario 19: Failed events are retried with backoff until they succeed or give up
+func TestHandlerRetry(t *testing.T) {
+	registry := newErrorRegistry()
+	tracker := newExecutionTracker()
+	errPermanent := errors.New("card declined")
+
+	var mu sync.Mutex
+	attempts := make(map[string][]time.Time)
+	record := func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[name] = append(attempts[name], time.Now())
+		return len(attempts[name])
+	}
+
+	// Succeeds on the third attempt
+	trackFlaky := tracker.track("flaky", nil, 0)
+	registry.RegisterErrorHandler("flaky", func(payload any) error {
+		if record("flaky") < 3 {
+			return errors.New("service unavailable")
+		}
+		trackFlaky(payload)
+		return nil
+	})
+	// Fails with an error that is not worth retrying
+	trackPayment := tracker.track("payment", nil, 0)
+	registry.RegisterErrorHandler("payment", func(payload any) error {
+		record("payment")
+		trackPayment(payload)
+		return errPermanent
+	})
+	// Always fails and runs out of attempts
+	trackBroken := tracker.track("broken", nil, 0)
+	registry.RegisterErrorHandler("broken", func(payload any) error {
+		if record("broken") == 3 {
+			trackBroken(payload)
+		}
+		return errors.New("still broken")
+	})
+
+	loop := NewEventLoop(10*time.Millisecond, registry, nil, WithResolution(Milliseconds))
+	policy := &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 30 * time.Millisecond,
+		Retryable:      func(err error) bool { return !errors.Is(err, errPermanent) },
+	}
+	for _, name := range []string{"flaky", "payment", "broken"} {
+		loop.SetHandlerOptions(name, HandlerOptions{Retry: policy})
+	}
+	loop.Start()
+	defer loop.Stop()
+
+	tracker.expectCount(3)
+	for _, name := range []string{"flaky", "payment", "broken"} {
+		if _, err := loop.ScheduleAfter(0, name, name); err != nil {
+			t.Fatalf("Failed to schedule event: %v", err)
+		}
+	}
+
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for retries. Got %d completions, expected 3", tracker.count())
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if n := len(attempts["flaky"]); n != 3 {
+		t.Errorf("Expected 'flaky' to run 3 times, ran %d", n)
+	}
+	if n := len(attempts["payment"]); n != 1 {
+		t.Errorf("Expected non-retryable 'payment' to run once, ran %d", n)
+	}
+	if n := len(attempts["broken"]); n != 3 {
+		t.Errorf("Expected 'broken' to stop after 3 attempts, ran %d", n)
+	}
+
+	// The second retry waits twice as long as the first, give or take the millisecond resolution
+	if runs := attempts["flaky"]; len(runs) == 3 {
+		if first := runs[1].Sub(runs[0]); first < 29*time.Millisecond {
+			t.Errorf("Expected the first retry after at least 30ms, got %v", first)
+		}
+		if second := runs[2].Sub(runs[1]); second < 59*time.Millisecond {
+			t.Errorf("Expected the second retry after at least 60ms, got %v", second)
+		}
+	}
+
+	t.Log("Successfully retried failed events according to the retry policy")
+}
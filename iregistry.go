@@ -1,6 +1,22 @@
 package eventgoround
 
+import "context"
+
 // Registry of event handlers. It allows you to retrieve events by name.
 type IEventRegistry interface {
 	GetHandler(name string) (func(any), error)
 }
+
+// Registry of event handlers that report failure by returning an error. If the registry given to
+// the event loop also implements this interface, its handlers take precedence over GetHandler and
+// failed events are retried according to the handler's RetryPolicy.
+type IErrorEventRegistry interface {
+	GetErrorHandler(name string) (func(any) error, error)
+}
+
+// Registry of event handlers that take a context. If the registry given to the event loop also
+// implements this interface, its handlers take precedence over the other registry interfaces.
+// The context carries the event and is cancelled when the loop stops or the handler's timeout elapses.
+type IContextEventRegistry interface {
+	GetContextHandler(name string) (func(ctx context.Context, ev Event), error)
+}
@@ -0,0 +1,129 @@
+package eventgoround
+
+import (
+	"fmt"
+	"slices"
+)
+
+// State is a stage in the lifecycle of an event loop
+type State int
+
+const (
+	// StateCreated is a loop that has not been started, events can already be scheduled
+	StateCreated State = iota
+	// StateRunning is a started loop firing events as they become due
+	StateRunning
+	// StatePaused is a started loop that neither fires nor accepts events
+	StatePaused
+	// StateStopping is a loop that is shutting down
+	StateStopping
+	// StateStopped is a loop that has stopped for good
+	StateStopped
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StateRunning:
+		return "running"
+	case StatePaused:
+		return "paused"
+	case StateStopping:
+		return "stopping"
+	case StateStopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// StateChange is sent to subscribers whenever the loop moves to another state
+type StateChange struct {
+	From State
+	To   State
+}
+
+// StateError is returned when a lifecycle call is not allowed in the loop's current state
+// It matches ErrLoopStopped with errors.Is if the loop is stopping or stopped
+type StateError struct {
+	Op    string // The call that failed, such as "start" or "pause"
+	State State  // The state the loop was in
+}
+
+// Error implements the error interface
+func (e *StateError) Error() string {
+	return fmt.Sprintf("cannot %s event loop: loop is %s", e.Op, e.State)
+}
+
+// Is reports whether the error means the loop has stopped
+func (e *StateError) Is(target error) bool {
+	return target == ErrLoopStopped && e.State >= StateStopping
+}
+
+// stateSubscriberBuffer is the number of changes a subscriber may fall behind before changes are dropped
+const stateSubscriberBuffer = 16
+
+// State returns the current lifecycle state of the loop
+func (el *EventLoop) State() State {
+	el.stateMu.Lock()
+	defer el.stateMu.Unlock()
+	return el.state
+}
+
+// SubscribeState returns a channel receiving every state change and a function to unsubscribe
+// The channel is closed once the loop has stopped or the subscription is cancelled. Changes are
+// dropped for subscribers that fall more than a few changes behind
+func (el *EventLoop) SubscribeState() (<-chan StateChange, func()) {
+	el.stateMu.Lock()
+	defer el.stateMu.Unlock()
+
+	ch := make(chan StateChange, stateSubscriberBuffer)
+	if el.state == StateStopped {
+		close(ch)
+		return ch, func() {}
+	}
+	el.subscribers = append(el.subscribers, ch)
+
+	return ch, func() {
+		el.stateMu.Lock()
+		defer el.stateMu.Unlock()
+		if i := slices.Index(el.subscribers, ch); i >= 0 {
+			el.subscribers = slices.Delete(el.subscribers, i, i+1)
+			close(ch)
+		}
+	}
+}
+
+// transition moves the loop from one of the allowed states to the target state and returns the state it left
+func (el *EventLoop) transition(op string, to State, from ...State) (State, error) {
+	el.stateMu.Lock()
+	defer el.stateMu.Unlock()
+
+	if !slices.Contains(from, el.state) {
+		return el.state, &StateError{Op: op, State: el.state}
+	}
+
+	change := StateChange{From: el.state, To: to}
+	el.state = to
+	for _, ch := range el.subscribers {
+		select {
+		case ch <- change:
+		default:
+			el.logError("state change dropped - subscriber is not keeping up", "from", change.From, "to", change.To)
+		}
+	}
+	if to == StateStopped {
+		for _, ch := range el.subscribers {
+			close(ch)
+		}
+		el.subscribers = nil
+	}
+	return change.From, nil
+}
+
+// isStopping reports whether the loop is stopping or has stopped
+func (el *EventLoop) isStopping() bool {
+	return el.State() >= StateStopping
+}
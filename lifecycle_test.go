@@ -0,0 +1,93 @@
+package eventgoround
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestLifecycle - Scenario 23: Lifecycle calls are validated and state changes are published
+func TestLifecycle(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("resumed", tracker.track("resumed", nil, 0))
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil)
+	changes, _ := loop.SubscribeState()
+
+	if state := loop.State(); state != StateCreated {
+		t.Fatalf("Expected a new loop to be created, got %s", state)
+	}
+
+	// Pausing a loop that never started used to block forever
+	var stateErr *StateError
+	if err := loop.Pause(); !errors.As(err, &stateErr) || stateErr.Op != "pause" || stateErr.State != StateCreated {
+		t.Errorf("Expected pausing before Start to fail with a state error, got %v", err)
+	}
+
+	if err := loop.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := loop.Start(); err == nil {
+		t.Error("Expected starting twice to fail")
+	}
+	if err := loop.Unpause(); err == nil {
+		t.Error("Expected unpausing a running loop to fail")
+	}
+
+	if err := loop.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if _, err := loop.ScheduleAfter(0, "resumed", nil); err == nil {
+		t.Error("Expected scheduling to fail while paused")
+	}
+	if err := loop.Unpause(); err != nil {
+		t.Fatalf("Unpause failed: %v", err)
+	}
+
+	// Pausing must not leave the loop stuck in catch-up mode
+	tracker.expectCount(1)
+	if _, err := loop.ScheduleAfter(0, "resumed", nil); err != nil {
+		t.Fatalf("Failed to schedule after unpausing: %v", err)
+	}
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for event scheduled after unpausing")
+	}
+
+	if err := loop.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := loop.Stop(); !errors.Is(err, ErrLoopStopped) {
+		t.Errorf("Expected stopping twice to fail with ErrLoopStopped, got %v", err)
+	}
+	if err := loop.Start(); !errors.Is(err, ErrLoopStopped) {
+		t.Errorf("Expected restarting a stopped loop to fail with ErrLoopStopped, got %v", err)
+	}
+
+	expected := []StateChange{
+		{StateCreated, StateRunning},
+		{StateRunning, StatePaused},
+		{StatePaused, StateRunning},
+		{StateRunning, StateStopping},
+		{StateStopping, StateStopped},
+	}
+	var got []StateChange
+	for change := range changes {
+		got = append(got, change)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected state changes %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Change %d: expected %v, got %v", i, expected[i], got[i])
+		}
+	}
+
+	late, _ := loop.SubscribeState()
+	if _, ok := <-late; ok {
+		t.Error("Expected subscribing to a stopped loop to return a closed channel")
+	}
+
+	t.Log("Successfully validated lifecycle transitions")
+}
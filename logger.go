@@ -10,6 +10,8 @@ import (
 const (
 	// DefaultMaxBytes is the default maximum size of log file before rotation (10MB)
 	DefaultMaxBytes = 10 * 1024 * 1024 // 10 megabytes
+	// DefaultMaxBackups is the default number of rotated log files kept next to the current one
+	DefaultMaxBackups = 5
 )
 
 // LogConfig holds configuration for event loop logging
@@ -24,20 +26,31 @@ type LogConfig struct {
 type RotatingFileWriter struct {
 	filepath    string
 	maxBytes    int64
+	maxBackups  int
 	currentFile *os.File
 	currentSize int64
 	mu          sync.Mutex
 }
 
-// NewRotatingFileWriter creates a new rotating file writer
+// NewRotatingFileWriter creates a new rotating file writer keeping DefaultMaxBackups rotated files
 func NewRotatingFileWriter(filepath string, maxBytes int64) (*RotatingFileWriter, error) {
+	return NewRotatingFileWriterWithBackups(filepath, maxBytes, DefaultMaxBackups)
+}
+
+// NewRotatingFileWriterWithBackups creates a new rotating file writer keeping up to maxBackups rotated files
+// Values of 0 or less use the defaults
+func NewRotatingFileWriterWithBackups(filepath string, maxBytes int64, maxBackups int) (*RotatingFileWriter, error) {
 	if maxBytes <= 0 {
 		maxBytes = DefaultMaxBytes
 	}
+	if maxBackups <= 0 {
+		maxBackups = DefaultMaxBackups
+	}
 
 	rfw := &RotatingFileWriter{
-		filepath: filepath,
-		maxBytes: maxBytes,
+		filepath:   filepath,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
 	}
 
 	// Open initial file
@@ -99,9 +112,8 @@ func (rfw *RotatingFileWriter) rotate() error {
 	}
 
 	// Rotate existing backup files (.1 -> .2, .2 -> .3, etc.)
-	// We'll keep up to 5 rotated files
-	maxBackups := 5
-	for i := maxBackups - 1; i >= 1; i-- {
+	// The oldest one is overwritten once maxBackups files exist
+	for i := rfw.maxBackups - 1; i >= 1; i-- {
 		oldPath := fmt.Sprintf("%s.%d", rfw.filepath, i)
 		newPath := fmt.Sprintf("%s.%d", rfw.filepath, i+1)
 
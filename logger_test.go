@@ -3,6 +3,8 @@ package eventgoround
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -93,3 +95,30 @@ func TestRotatingFileWriterConcurrent(t *testing.T) {
 
 	t.Logf("Concurrent write test successful: %d bytes written", info.Size())
 }
+
+func TestRotatingFileWriterBackups(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "backups.log")
+
+	writer, err := NewRotatingFileWriterWithBackups(logFile, 100, 2)
+	if err != nil {
+		t.Fatalf("Failed to create rotating file writer: %v", err)
+	}
+	defer writer.Close()
+
+	// Every write fills a file, so each one after the first rotates
+	line := []byte(strings.Repeat("x", 99) + "\n")
+	for i := 0; i < 5; i++ {
+		if _, err := writer.Write(line); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	for _, suffix := range []string{"", ".1", ".2"} {
+		if _, err := os.Stat(logFile + suffix); err != nil {
+			t.Errorf("Expected %s to exist: %v", logFile+suffix, err)
+		}
+	}
+	if _, err := os.Stat(logFile + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected no more than 2 backups, found %s", logFile+".3")
+	}
+}
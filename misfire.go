@@ -0,0 +1,67 @@
+package eventgoround
+
+// MisfirePolicy decides what catch-up does with events that became due while the loop could not fire them
+type MisfirePolicy int
+
+const (
+	// MisfireFireAll fires every missed event in order (default)
+	MisfireFireAll MisfirePolicy = iota
+	// MisfireFireOnce fires only the latest missed event per handler and partition key, so a
+	// recurring series fires once however many occurrences it missed
+	MisfireFireOnce
+	// MisfireSkip drops missed events, recurring series carry on with their next occurrence
+	MisfireSkip
+	// MisfireReschedule moves missed events to now plus their Duration, as if they had been scheduled
+	// now, which for a recurring series means one interval from now. A negative Duration moves them to now
+	MisfireReschedule
+)
+
+// misfireAction is what a catch-up pass does with a single missed event
+type misfireAction int
+
+const (
+	misfireFire misfireAction = iota
+	misfireSkip
+	misfireCoalesce
+	misfireReschedule
+)
+
+// coalesceKey groups missed events that MisfireFireOnce fires only once
+type coalesceKey struct {
+	handler      string
+	partitionKey string
+}
+
+// misfireAction decides what to do with an event popped while catching up
+// Events less than the handler's MisfireThreshold late, by default one tick interval, are not misfired and fire as usual
+func (el *EventLoop) misfireAction(event Event, c *catchUp) misfireAction {
+	if c == nil {
+		return misfireFire
+	}
+
+	opts := el.handlerOptions(event.Handler)
+
+	// Later events supersede missed ones already coalesced, even if they are on time
+	if opts.Misfire == MisfireFireOnce && c.has(event) {
+		return misfireCoalesce
+	}
+
+	threshold := opts.MisfireThreshold
+	if threshold <= 0 {
+		threshold = el.tickInterval
+	}
+	if c.now-(event.Timestamp+event.Duration) <= el.resolution.fromDuration(threshold) {
+		return misfireFire
+	}
+
+	switch opts.Misfire {
+	case MisfireFireOnce:
+		return misfireCoalesce
+	case MisfireSkip:
+		return misfireSkip
+	case MisfireReschedule:
+		return misfireReschedule
+	default:
+		return misfireFire
+	}
+}
@@ -0,0 +1,118 @@
+package eventgoround
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMisfirePolicies - Scenario 26: Catch-up after an outage applies each handler's misfire policy
+func TestMisfirePolicies(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	for _, name := range []string{"digest", "reminder", "loot", "build"} {
+		registry.RegisterHandler(name, tracker.track(name, nil, 0))
+	}
+
+	clock := NewFakeClock(start)
+	loop := NewEventLoop(time.Second, registry, nil, WithClock(clock))
+	loop.SetHandlerOptions("digest", HandlerOptions{Misfire: MisfireFireOnce})
+	loop.SetHandlerOptions("reminder", HandlerOptions{Misfire: MisfireSkip, MisfireThreshold: time.Minute})
+	loop.SetHandlerOptions("build", HandlerOptions{Misfire: MisfireReschedule})
+
+	now := start.Unix()
+	loop.ScheduleRecurring(now, 60, "digest", "daily", nil)
+	loop.ScheduleEvent(now, 10, "reminder", "stale")
+	loop.ScheduleEvent(now, 10, "loot", 1)
+	loop.ScheduleEvent(now, 20, "loot", 2)
+	loop.ScheduleEvent(now, 30, "loot", 3)
+	loop.ScheduleEvent(now, 30, "build", "barracks")
+
+	// The loop comes up an hour late, with one reminder only just missed
+	clock.Advance(time.Hour)
+	loop.ScheduleEvent(now, 3600-30, "reminder", "recent")
+	loop.Start()
+	defer loop.Stop()
+
+	tracker.expectCount(5)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for catch-up. Got %d executions, expected 5", tracker.count())
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	counts := make(map[string]int)
+	for _, e := range tracker.getExecutions() {
+		counts[e.handlerName]++
+		if e.handlerName == "reminder" && e.payload != "recent" {
+			t.Errorf("Expected only the recent reminder to fire, got %v", e.payload)
+		}
+	}
+	expected := map[string]int{"digest": 1, "reminder": 1, "loot": 3}
+	for name, want := range expected {
+		if counts[name] != want {
+			t.Errorf("Expected '%s' to fire %d times during catch-up, fired %d", name, want, counts[name])
+		}
+	}
+	if counts["build"] != 0 {
+		t.Error("Expected the rescheduled 'build' event not to fire during catch-up")
+	}
+
+	// The build event runs its 30 seconds again from when the loop came back
+	tracker.expectCount(1)
+	clock.Advance(30 * time.Second)
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatal("Timeout waiting for the rescheduled event")
+	}
+
+	t.Log("Successfully applied misfire policies while catching up")
+}
+
+// TestMisfireRescheduleNegativeDuration - Scenario 31: Rescheduling a missed event with a negative duration fires it once instead of catching it up forever
+func TestMisfireRescheduleNegativeDuration(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("build", tracker.track("build", nil, 0))
+
+	clock := NewFakeClock(start)
+	loop := NewEventLoop(time.Second, registry, nil, WithClock(clock))
+	loop.SetHandlerOptions("build", HandlerOptions{Misfire: MisfireReschedule})
+
+	now := start.Unix()
+	loop.ScheduleEvent(now, -100, "build", "negative")
+	handle, _ := loop.ScheduleEvent(now, 10, "build", "moved")
+	if err := loop.Reschedule(handle.ID(), now-100); err != nil {
+		t.Fatalf("Failed to reschedule event: %v", err)
+	}
+
+	clock.Advance(time.Hour)
+	tracker.expectCount(2)
+	loop.Start()
+	defer loop.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for the rescheduled events. Got %d executions, expected 2", tracker.count())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for loop.IsCatchingUp() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status := loop.CatchUpStatus(); status.Active || status.Processed != 2 {
+		t.Errorf("Expected catch-up to process both events once and finish, got %+v", status)
+	}
+
+	clock.Advance(time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	if count := tracker.count(); count != 2 {
+		t.Errorf("Expected each event to fire once, got %d executions", count)
+	}
+
+	t.Log("Successfully rescheduled missed events with negative durations")
+}
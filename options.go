@@ -0,0 +1,135 @@
+package eventgoround
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Option configures optional behaviour of an EventLoop
+type Option func(*EventLoop)
+
+const (
+	// DefaultTickInterval is how often New checks for due events unless WithTickInterval is given
+	DefaultTickInterval = time.Second
+	// DefaultChannelCapacity is the number of scheduled events handed straight to the run loop
+	DefaultChannelCapacity = 2000
+)
+
+// WithTickInterval sets how often the loop checks for due events in WakeOnTick mode, where New
+// rejects intervals that are not positive
+func WithTickInterval(interval time.Duration) Option {
+	return func(el *EventLoop) {
+		el.tickInterval = interval
+	}
+}
+
+// WithChannelCapacity sets how many scheduled events are handed straight to the run loop,
+// DefaultChannelCapacity for values below 1. Scheduling never blocks on it: further events
+// wait until the run loop next wakes up, such as after catching up
+func WithChannelCapacity(capacity int) Option {
+	return func(el *EventLoop) {
+		el.eventChanSize = capacity
+	}
+}
+
+// WithLogger logs through the given logger, including informational messages
+// Use the logger's handler to filter levels. It takes precedence over WithLogConfig
+func WithLogger(logger *slog.Logger) Option {
+	return func(el *EventLoop) {
+		el.logger = logger
+		el.includeInfo = true
+	}
+}
+
+// WithLogConfig logs as JSON to the rotating file described by config, nil disables file logging
+func WithLogConfig(config *LogConfig) Option {
+	return func(el *EventLoop) {
+		el.logConfig = config
+	}
+}
+
+// WithLogRotation sets the size at which the log file rotates and how many rotated files are kept,
+// DefaultMaxBytes and DefaultMaxBackups for values of 0 or less
+func WithLogRotation(maxBytes int64, maxBackups int) Option {
+	return func(el *EventLoop) {
+		el.logMaxBytes = maxBytes
+		el.logMaxBackups = maxBackups
+	}
+}
+
+// WakeMode controls how the event loop decides when to look for due events
+type WakeMode int
+
+const (
+	// WakeOnTick checks for due events on every tick interval
+	WakeOnTick WakeMode = iota
+	// WakeOnDue sleeps until the earliest pending event is due, ignoring the tick interval
+	WakeOnDue
+)
+
+// WithWakeMode sets how the event loop decides when to look for due events
+func WithWakeMode(mode WakeMode) Option {
+	return func(el *EventLoop) {
+		el.wakeMode = mode
+	}
+}
+
+// Resolution is the unit event timestamps, durations and intervals are expressed in
+type Resolution int
+
+const (
+	// Seconds interprets timestamps as Unix seconds (default)
+	Seconds Resolution = iota
+	// Milliseconds interprets timestamps as Unix milliseconds
+	Milliseconds
+	// Microseconds interprets timestamps as Unix microseconds
+	Microseconds
+)
+
+// WithResolution sets the unit event timestamps and durations are expressed in
+func WithResolution(resolution Resolution) Option {
+	return func(el *EventLoop) {
+		el.resolution = resolution
+	}
+}
+
+// unit returns the length of one timestamp step
+func (r Resolution) unit() time.Duration {
+	switch r {
+	case Milliseconds:
+		return time.Millisecond
+	case Microseconds:
+		return time.Microsecond
+	default:
+		return time.Second
+	}
+}
+
+// fromTime converts a time into a timestamp, truncating anything below the resolution
+func (r Resolution) fromTime(t time.Time) int64 {
+	switch r {
+	case Milliseconds:
+		return t.UnixMilli()
+	case Microseconds:
+		return t.UnixMicro()
+	default:
+		return t.Unix()
+	}
+}
+
+// toTime converts a timestamp into a time
+func (r Resolution) toTime(timestamp int64) time.Time {
+	switch r {
+	case Milliseconds:
+		return time.UnixMilli(timestamp)
+	case Microseconds:
+		return time.UnixMicro(timestamp)
+	default:
+		return time.Unix(timestamp, 0)
+	}
+}
+
+// fromDuration converts a duration into timestamp steps, truncating anything below the resolution
+func (r Resolution) fromDuration(d time.Duration) int64 {
+	return int64(d / r.unit())
+}
@@ -0,0 +1,71 @@
+package eventgoround
+
+import (
+	"math"
+	"time"
+)
+
+// PauseMode decides what happens to pending events while the loop is paused
+type PauseMode int
+
+const (
+	// PauseHold keeps pending events at their due times, so events that became due while paused
+	// fire through catch-up on Unpause (default)
+	PauseHold PauseMode = iota
+	// PauseShift delays every pending event by the time the loop was paused, like a game clock
+	// that stops, so a 10 minute pause delays all timers by 10 minutes and the Until of their series
+	PauseShift
+)
+
+// WithPauseMode sets what happens to pending events while the loop is paused, PauseHold by default
+func WithPauseMode(mode PauseMode) Option {
+	return func(el *EventLoop) {
+		el.pauseMode = mode
+	}
+}
+
+// shiftPending moves every pending event d later, including events still on their way to storage,
+// and returns the number of events moved. The caller must hold pendingMu
+func (el *EventLoop) shiftPending(d time.Duration) int {
+	delta := el.resolution.fromDuration(d)
+	if delta <= 0 {
+		return 0
+	}
+
+	// Taking every event out in due order and adding them back in that order keeps
+	// events that share a due time in the order they were scheduled
+	events, err := el.storage.PopDue(math.MaxInt64)
+	if err != nil {
+		el.logError("failed to shift events - storage error", "error", err)
+		return 0
+	}
+
+	records := make([]logRecord, 0, len(events)+len(el.staged))
+	for _, event := range events {
+		event = event.shifted(delta)
+		records = append(records, logRecord{Op: logOpSchedule, ID: event.ID, Event: &event})
+		if err := el.storage.Add(event); err != nil {
+			el.logError("failed to store shifted event", "id", event.ID, "error", err)
+		}
+	}
+
+	for id, event := range el.staged {
+		event = event.shifted(delta)
+		records = append(records, logRecord{Op: logOpSchedule, ID: id, Event: &event})
+		el.staged[id] = event
+	}
+
+	el.persist(records...)
+	return len(records)
+}
+
+// shifted returns the event delta later, together with the end of its series so that no occurrence is cut off
+func (e Event) shifted(delta int64) Event {
+	e.Timestamp += delta
+	if e.Recurrence != nil && e.Recurrence.Until > 0 {
+		recurrence := *e.Recurrence
+		recurrence.Until += delta
+		e.Recurrence = &recurrence
+	}
+	return e
+}
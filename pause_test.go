@@ -0,0 +1,105 @@
+package eventgoround
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestPauseModes - Scenario 29: Unpausing either catches up on events due while paused or delays them by the pause
+func TestPauseModes(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   PauseMode
+		before time.Duration // Time after Unpause the event must not have fired by
+		after  time.Duration // Further time after which it must have fired
+	}{
+		{name: "hold", mode: PauseHold, before: 0, after: time.Second},
+		{name: "shift", mode: PauseShift, before: 9 * time.Second, after: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			registry := newMockRegistry()
+			tracker := newExecutionTracker()
+			registry.RegisterHandler("respawn", tracker.track("respawn", nil, 0))
+
+			clock := NewFakeClock(start)
+			loop := NewEventLoop(time.Second, registry, nil, WithClock(clock), WithPauseMode(tt.mode))
+			loop.ScheduleEvent(start.Unix(), 10, "respawn", "boss")
+			loop.Start()
+			defer loop.Stop()
+			clock.BlockUntil(1)
+
+			// A minute long maintenance pause passes the event's due time
+			if err := loop.Pause(); err != nil {
+				t.Fatalf("Pause failed: %v", err)
+			}
+			clock.Advance(time.Minute)
+			if err := loop.Unpause(); err != nil {
+				t.Fatalf("Unpause failed: %v", err)
+			}
+
+			if tt.before > 0 {
+				clock.Advance(tt.before)
+				time.Sleep(50 * time.Millisecond)
+				if tracker.count() != 0 {
+					t.Fatalf("Expected the event to be delayed by the pause, it fired %v after unpausing", tt.before)
+				}
+			}
+
+			tracker.expectCount(1)
+			clock.Advance(tt.after)
+			if !tracker.waitWithTimeout(2 * time.Second) {
+				t.Fatalf("Timeout waiting for the event %v after unpausing", tt.before+tt.after)
+			}
+		})
+	}
+
+	t.Log("Successfully held and shifted pending events across a pause")
+}
+
+// TestPauseShiftOrder - Scenario 39: Shifting keeps events that share a due time in order and moves the end of bounded series
+func TestPauseShiftOrder(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	registry := newMockRegistry()
+	registry.RegisterHandler("respawn", func(any) {})
+	registry.RegisterHandler("spawn-wave", func(any) {})
+
+	clock := NewFakeClock(start)
+	loop := NewEventLoop(time.Second, registry, nil, WithClock(clock), WithPauseMode(PauseShift))
+	for i := range 200 {
+		loop.ScheduleEvent(start.Unix(), 10, "respawn", i)
+	}
+	series, _ := loop.ScheduleRecurring(start.Unix()+5, 5, "spawn-wave", nil, &RecurringOptions{Until: start.Unix() + 15})
+	loop.storeStaged()
+
+	loop.Start()
+	clock.BlockUntil(1)
+	if err := loop.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	clock.Advance(time.Minute)
+	if err := loop.Unpause(); err != nil {
+		t.Fatalf("Unpause failed: %v", err)
+	}
+	loop.Stop()
+
+	events, _ := loop.storage.PopDue(math.MaxInt64)
+	if len(events) != 201 {
+		t.Fatalf("Expected 201 pending events, got %d", len(events))
+	}
+	if wave := events[0]; wave.ID != series.ID() || wave.Recurrence.Until != start.Unix()+75 {
+		t.Errorf("Expected the series to end a minute later at %d, got %+v", start.Unix()+75, wave.Recurrence)
+	}
+	for i, event := range events[1:] {
+		if event.Payload != i {
+			t.Fatalf("Position %d: expected event %d, got %v", i, i, event.Payload)
+		}
+	}
+
+	t.Log("Successfully shifted pending events in order")
+}
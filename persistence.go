@@ -0,0 +1,335 @@
+package eventgoround
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Operations recorded in the event log
+const (
+	logOpSchedule = "schedule" // Event added or changed, replaces any earlier record with the same ID
+	logOpCancel   = "cancel"   // Event cancelled before it fired
+	logOpFire     = "fire"     // Event fired
+	logOpSequence = "sequence" // Highest event ID handed out so far, written on compaction
+)
+
+// recordHeaderSize is the size of the length and CRC32 checksum preceding every record
+const recordHeaderSize = 8
+
+const (
+	// compactMinRecords is the number of records appended since the last compaction below which the
+	// running loop never compacts the event log
+	compactMinRecords = 1000
+	// compactRatio is how many records per pending event may be appended before the running loop
+	// compacts the event log
+	compactRatio = 4
+)
+
+// WithPersistence enables a write-ahead log at the given path
+// Every schedule, cancel and fire is appended to the log and synced to disk before it takes effect.
+// On start-up the log is replayed to rebuild pending events, and anything that became due while
+// the process was down is fired by the regular catch-up. Payloads must be JSON serialisable and are
+// restored as decoded by encoding/json (for example map[string]any for structs)
+func WithPersistence(path string) Option {
+	return func(el *EventLoop) {
+		el.persistPath = path
+	}
+}
+
+// logRecord is a single entry of the event log
+type logRecord struct {
+	Op    string `json:"op"`
+	ID    uint64 `json:"id"`
+	Event *Event `json:"event,omitempty"`
+}
+
+// eventLog is an append-only log of changes to pending events
+// Each record is framed as a 4 byte big endian length, a 4 byte CRC32 of the data and the JSON data
+type eventLog struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	appended int // Records appended since the log was last compacted
+}
+
+// openEventLog replays the log at path and compacts it so that it only holds pending events
+// It returns the pending events ordered by ID and the highest event ID seen
+func openEventLog(path string) (*eventLog, []Event, uint64, error) {
+	pending, maxID, err := replayEventLog(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if err := compactEventLog(path, pending, maxID); err != nil {
+		return nil, nil, 0, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to open event log: %w", err)
+	}
+
+	return &eventLog{path: path, file: file}, pending, maxID, nil
+}
+
+// replayEventLog reads every intact record of the log and returns the events still pending
+// A truncated or corrupt last record is what a crash mid-write leaves behind and is dropped, while a
+// corrupt record followed by more data fails the replay so that compaction cannot discard what follows
+func replayEventLog(path string) ([]Event, uint64, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer file.Close()
+
+	events := make(map[uint64]Event)
+	var maxID uint64
+	var records int
+	reader := bufio.NewReader(file)
+
+	for {
+		record, err := readLogRecord(reader)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				break
+			}
+			return nil, 0, fmt.Errorf("event log %s is corrupt after %d records: %w", path, records, err)
+		}
+		records++
+
+		maxID = max(maxID, record.ID)
+		switch record.Op {
+		case logOpSchedule:
+			if record.Event != nil {
+				events[record.ID] = *record.Event
+			}
+		case logOpCancel, logOpFire:
+			delete(events, record.ID)
+		}
+	}
+
+	pending := make([]Event, 0, len(events))
+	for _, event := range events {
+		pending = append(pending, event)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ID < pending[j].ID
+	})
+
+	return pending, maxID, nil
+}
+
+// compactEventLog atomically replaces the log with one holding only the pending events
+func compactEventLog(path string, pending []Event, maxID uint64) error {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted event log: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	err = writeLogRecord(writer, logRecord{Op: logOpSequence, ID: maxID})
+	for i := 0; err == nil && i < len(pending); i++ {
+		err = writeLogRecord(writer, logRecord{Op: logOpSchedule, ID: pending[i].ID, Event: &pending[i]})
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write compacted event log: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace event log: %w", err)
+	}
+	return nil
+}
+
+// readLogRecord reads and verifies the next record
+func readLogRecord(reader io.Reader) (logRecord, error) {
+	var record logRecord
+
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return record, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return record, err
+	}
+
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:8]) {
+		return record, fmt.Errorf("event log record checksum mismatch")
+	}
+
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("failed to decode event log record: %w", err)
+	}
+	return record, nil
+}
+
+// writeLogRecord frames and writes a record
+func writeLogRecord(writer io.Writer, record logRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode event log record: %w", err)
+	}
+
+	frame := make([]byte, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(data))
+	copy(frame[recordHeaderSize:], data)
+
+	_, err = writer.Write(frame)
+	return err
+}
+
+// append writes the records in one go and syncs them to disk
+func (l *eventLog) append(records ...logRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("event log is closed")
+	}
+
+	writer := bufio.NewWriter(l.file)
+	for _, record := range records {
+		if err := writeLogRecord(writer, record); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write event log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync event log: %w", err)
+	}
+	l.appended += len(records)
+	return nil
+}
+
+// compact replaces the log with one holding only the pending events and appends to it from then on
+func (l *eventLog) compact(pending []Event, maxID uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("event log is closed")
+	}
+
+	if err := compactEventLog(l.path, pending, maxID); err != nil {
+		return err
+	}
+
+	// The old file was replaced, appending to it would lose records on the next start
+	l.file.Close()
+	l.file = nil
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen compacted event log: %w", err)
+	}
+	l.file = file
+	l.appended = 0
+	return nil
+}
+
+// appendedRecords returns the number of records appended since the log was last compacted
+func (l *eventLog) appendedRecords() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.appended
+}
+
+// Close closes the underlying file
+func (l *eventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		err := l.file.Close()
+		l.file = nil
+		return err
+	}
+	return nil
+}
+
+// Compact rewrites the event log so that it only holds the pending events
+// The running loop also compacts it once far more records were appended than there are pending events.
+// It does nothing if persistence is not enabled
+func (el *EventLoop) Compact() error {
+	if el.eventLog == nil {
+		return nil
+	}
+
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+	return el.compactLog()
+}
+
+// maybeCompact compacts the event log if it has grown well beyond the pending events
+func (el *EventLoop) maybeCompact() {
+	if el.eventLog == nil {
+		return
+	}
+
+	el.pendingMu.Lock()
+	defer el.pendingMu.Unlock()
+
+	pending := el.storage.Len() + len(el.staged)
+	if el.eventLog.appendedRecords() <= max(compactMinRecords, compactRatio*pending) {
+		return
+	}
+	el.compactLog()
+}
+
+// compactLog compacts the event log down to the events in storage and those staged
+// The caller must hold pendingMu so that the log matches them
+func (el *EventLoop) compactLog() error {
+	pending := make([]Event, 0, el.storage.Len()+len(el.staged))
+	el.storage.Iterate(func(event Event) bool {
+		pending = append(pending, event)
+		return true
+	})
+	for _, event := range el.staged {
+		pending = append(pending, event)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ID < pending[j].ID
+	})
+
+	appended := el.eventLog.appendedRecords()
+	if err := el.eventLog.compact(pending, el.nextID.Load()); err != nil {
+		el.logError("failed to compact event log", "error", err)
+		return fmt.Errorf("failed to compact event log: %w", err)
+	}
+	el.logInfo("event log compacted", "appendedRecords", appended, "pendingEventCount", len(pending))
+	return nil
+}
@@ -0,0 +1,344 @@
+package eventgoround
+
+import (
+	"bufio"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEventLogReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	log, pending, maxID, err := openEventLog(path)
+	if err != nil {
+		t.Fatalf("Failed to open event log: %v", err)
+	}
+	if len(pending) != 0 || maxID != 0 {
+		t.Fatalf("Expected a new log to be empty, got %d events and max ID %d", len(pending), maxID)
+	}
+
+	events := []Event{
+		{ID: 1, Timestamp: 100, Handler: "a", Payload: "one"},
+		{ID: 2, Timestamp: 200, Handler: "b", Payload: "two"},
+		{ID: 3, Timestamp: 300, Handler: "c", Payload: "three"},
+	}
+	for i := range events {
+		if err := log.append(logRecord{Op: logOpSchedule, ID: events[i].ID, Event: &events[i]}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	log.append(logRecord{Op: logOpCancel, ID: 2}, logRecord{Op: logOpFire, ID: 3})
+
+	// Rescheduling replaces the earlier record
+	moved := Event{ID: 1, Timestamp: 100, Duration: 50, Handler: "a", Payload: "one"}
+	log.append(logRecord{Op: logOpSchedule, ID: 1, Event: &moved})
+	log.Close()
+
+	// Replay twice to make sure compaction keeps the state intact
+	for i := 0; i < 2; i++ {
+		log, pending, maxID, err = openEventLog(path)
+		if err != nil {
+			t.Fatalf("Failed to reopen event log: %v", err)
+		}
+		log.Close()
+
+		if maxID != 3 {
+			t.Errorf("Expected max ID 3 to survive replay, got %d", maxID)
+		}
+		if len(pending) != 1 {
+			t.Fatalf("Expected 1 pending event, got %d", len(pending))
+		}
+		if pending[0].ID != 1 || pending[0].Duration != 50 || pending[0].Payload != "one" {
+			t.Errorf("Unexpected pending event after replay: %+v", pending[0])
+		}
+	}
+}
+
+func TestEventLogTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	log, _, _, err := openEventLog(path)
+	if err != nil {
+		t.Fatalf("Failed to open event log: %v", err)
+	}
+	event := Event{ID: 1, Timestamp: 100, Handler: "a"}
+	log.append(logRecord{Op: logOpSchedule, ID: 1, Event: &event})
+	log.Close()
+
+	// Simulate a crash in the middle of writing a record
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	file.Write([]byte{0, 0, 0, 200, 1, 2, 3, 4, '{', '"'})
+	file.Close()
+
+	_, pending, _, err := openEventLog(path)
+	if err != nil {
+		t.Fatalf("Failed to replay log with a torn record: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != 1 {
+		t.Errorf("Expected the intact record to be replayed, got %+v", pending)
+	}
+}
+
+func TestEventLogCorruptRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	log, _, _, err := openEventLog(path)
+	if err != nil {
+		t.Fatalf("Failed to open event log: %v", err)
+	}
+	events := make([]Event, 5)
+	for i := range events {
+		events[i] = Event{ID: uint64(i + 1), Timestamp: int64(100 * (i + 1)), Handler: "a"}
+		log.append(logRecord{Op: logOpSchedule, ID: events[i].ID, Event: &events[i]})
+	}
+	log.Close()
+
+	// Flip a byte inside the second record, after the sequence record and the first event
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	offset := 0
+	for range 2 {
+		offset += recordHeaderSize + int(binary.BigEndian.Uint32(data[offset:offset+4]))
+	}
+	data[offset+recordHeaderSize+2] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	// The intact records after the corrupt one must not be compacted away
+	if _, _, _, err := openEventLog(path); err == nil {
+		t.Fatal("Expected replaying a log corrupt before its end to fail")
+	}
+	if _, err := New(newMockRegistry(), WithPersistence(path)); err == nil {
+		t.Error("Expected New to fail on a log corrupt before its end")
+	}
+	if after, err := os.ReadFile(path); err != nil || len(after) != len(data) {
+		t.Errorf("Expected the corrupt log to be left as it was, %d bytes became %d", len(data), len(after))
+	}
+}
+
+// TestPersistenceRestart - Scenario 14: Pending events survive a restart and fire through catch-up
+func TestPersistenceRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("upgrade", tracker.track("upgrade", nil, 0))
+	registry.RegisterHandler("cancelled", tracker.track("cancelled", nil, 0))
+
+	// First process schedules two events and cancels one of them before going down
+	clock := NewFakeClock(start)
+	loop := NewEventLoop(time.Second, registry, nil, WithClock(clock), WithPersistence(path))
+	if _, err := loop.ScheduleAfter(time.Hour, "upgrade", "barracks"); err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	handle, _ := loop.ScheduleAfter(time.Hour, "cancelled", nil)
+	handle.Cancel()
+	loop.Stop()
+
+	// Second process comes back two hours later
+	clock = NewFakeClock(start.Add(2 * time.Hour))
+	loop = NewEventLoop(time.Second, registry, nil, WithClock(clock), WithPersistence(path))
+	loop.Start()
+	defer loop.Stop()
+
+	tracker.expectCount(1)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	if !tracker.waitWithTimeout(time.Second) {
+		t.Fatal("Timeout waiting for the restored event to fire")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	executions := tracker.getExecutions()
+	if len(executions) != 1 || executions[0].handlerName != "upgrade" || executions[0].payload != "barracks" {
+		t.Errorf("Expected only the restored 'upgrade' event to fire, got %v", executions)
+	}
+
+	// New events must not reuse IDs from before the restart
+	next, err := loop.ScheduleAfter(time.Hour, "upgrade", nil)
+	if err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	if next.ID() <= handle.ID() {
+		t.Errorf("Expected new IDs to continue after %d, got %d", handle.ID(), next.ID())
+	}
+
+	t.Log("Successfully restored pending events from the event log")
+}
+
+// TestPersistenceCompaction - Scenario 34: The event log is compacted while the loop runs and on request
+func TestPersistenceCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	registry := newMockRegistry()
+	registry.RegisterHandler("upgrade", func(any) {})
+
+	loop, err := New(registry, WithTickInterval(10*time.Millisecond), WithPersistence(path))
+	if err != nil {
+		t.Fatalf("Failed to create event loop: %v", err)
+	}
+	if _, err := loop.ScheduleAfter(time.Hour, "upgrade", "barracks"); err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	loop.Start()
+
+	// Scheduling and cancelling leaves thousands of records behind a single pending event
+	for range compactMinRecords {
+		handle, err := loop.ScheduleAfter(time.Hour, "upgrade", nil)
+		if err != nil {
+			t.Fatalf("Failed to schedule event: %v", err)
+		}
+		handle.Cancel()
+	}
+
+	// 2001 records were written, compaction keeps no more than those appended since it last ran
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n := countLogRecords(t, path)
+		if n <= compactMinRecords+2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for the running loop to compact the event log, it holds %d records", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Compact does not wait for the log to grow
+	handle, _ := loop.ScheduleAfter(time.Hour, "upgrade", nil)
+	handle.Cancel()
+	if err := loop.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if n := countLogRecords(t, path); n != 2 {
+		t.Errorf("Expected 2 records after Compact, got %d", n)
+	}
+
+	// Records appended after compaction go to the new file
+	if _, err := loop.ScheduleAfter(time.Hour, "upgrade", "farm"); err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	loop.Stop()
+
+	restored, err := New(registry, WithPersistence(path))
+	if err != nil {
+		t.Fatalf("Failed to reopen event loop: %v", err)
+	}
+	defer restored.Stop()
+	if n := restored.storage.Len(); n != 2 {
+		t.Errorf("Expected 2 pending events after restart, got %d", n)
+	}
+
+	t.Log("Successfully compacted the event log while running")
+}
+
+// TestPersistenceWriteFailure - Scenario 35: Cancelling and rescheduling fail and leave the event as it was if the event log cannot be written
+func TestPersistenceWriteFailure(t *testing.T) {
+	registry := newMockRegistry()
+	registry.RegisterHandler("upgrade", func(any) {})
+
+	loop, err := New(registry, WithPersistence(filepath.Join(t.TempDir(), "events.wal")))
+	if err != nil {
+		t.Fatalf("Failed to create event loop: %v", err)
+	}
+	defer loop.Stop()
+
+	stored, _ := loop.ScheduleAfter(time.Hour, "upgrade", nil)
+	loop.storeStaged()
+	staged, _ := loop.ScheduleAfter(time.Hour, "upgrade", nil)
+
+	// Losing the event log makes every further write fail
+	loop.eventLog.file.Close()
+
+	for _, handle := range []*EventHandle{stored, staged} {
+		if handle.Cancel() {
+			t.Errorf("Expected cancelling event %d to fail without an event log", handle.ID())
+		}
+		if err := loop.Shorten(handle.ID(), 60); err == nil {
+			t.Errorf("Expected rescheduling event %d to fail without an event log", handle.ID())
+		}
+	}
+
+	if n := loop.storage.Len(); n != 1 {
+		t.Errorf("Expected the stored event to stay in storage, got %d events", n)
+	}
+	if event, ok := loop.staged[staged.ID()]; !ok || event.Duration != 3600 {
+		t.Errorf("Expected the staged event to stay unchanged, got %+v", event)
+	}
+
+	t.Log("Successfully kept events unchanged when the event log could not be written")
+}
+
+// TestPersistenceLateHandler - Scenario 36: Restored events whose handler is registered after the loop is created stay pending
+func TestPersistenceLateHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	registry := newMockRegistry()
+	registry.RegisterHandler("upgrade", func(any) {})
+	loop, err := New(registry, WithPersistence(path))
+	if err != nil {
+		t.Fatalf("Failed to create event loop: %v", err)
+	}
+	loop.ScheduleEvent(time.Now().Unix(), 1, "upgrade", "barracks")
+	loop.Stop()
+
+	// The next two processes come up before registering the handler
+	late := newMockRegistry()
+	for range 2 {
+		restored, err := New(late, WithPersistence(path))
+		if err != nil {
+			t.Fatalf("Expected a missing handler not to fail New, got %v", err)
+		}
+		if n := restored.storage.Len(); n != 1 {
+			t.Errorf("Expected the event to be restored without its handler, got %d events", n)
+		}
+		restored.Stop()
+	}
+
+	tracker := newExecutionTracker()
+	restored, err := New(late, WithTickInterval(10*time.Millisecond), WithPersistence(path))
+	if err != nil {
+		t.Fatalf("Failed to reopen event loop: %v", err)
+	}
+	defer restored.Stop()
+	late.RegisterHandler("upgrade", tracker.track("upgrade", nil, 0))
+
+	tracker.expectCount(1)
+	restored.Start()
+	if !tracker.waitWithTimeout(3 * time.Second) {
+		t.Fatal("Timeout waiting for the restored event to fire once its handler was registered")
+	}
+
+	t.Log("Successfully kept restored events until their handler was registered")
+}
+
+// countLogRecords returns the number of intact records in the event log at path
+func countLogRecords(t *testing.T, path string) int {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	n := 0
+	for {
+		if _, err := readLogRecord(reader); err != nil {
+			return n
+		}
+		n++
+	}
+}
@@ -0,0 +1,204 @@
+package eventgoround
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// PoolFullPolicy decides what happens to an event when the worker pool queue is full
+type PoolFullPolicy int
+
+const (
+	// PoolBlock makes the event loop wait until there is room in the queue
+	PoolBlock PoolFullPolicy = iota
+	// PoolDrop drops the event and logs an error
+	PoolDrop
+	// PoolSpill keeps the event in an unbounded overflow list that workers drain once the queue has caught up
+	PoolSpill
+)
+
+// PoolConfig configures the bounded worker pool that runs event handlers
+type PoolConfig struct {
+	Workers    int            // Number of handler goroutines, at least 1
+	QueueDepth int            // Number of events that may wait for a free worker
+	WhenFull   PoolFullPolicy // What to do with events that do not fit in the queue
+}
+
+// PoolStats reports the state of the worker pool
+type PoolStats struct {
+	Workers    int    // Number of handler goroutines
+	QueueDepth int    // Capacity of the queue
+	Queued     int    // Events waiting in the queue
+	Spilled    int    // Events waiting in the overflow list
+	Active     int    // Handlers currently running
+	Submitted  uint64 // Events handed to the pool
+	Completed  uint64 // Handlers that finished
+	Dropped    uint64 // Events dropped because the queue was full
+	Saturated  uint64 // Times an event found the queue full
+}
+
+// WithWorkerPool runs handlers on a bounded pool of goroutines instead of one goroutine per event
+func WithWorkerPool(config PoolConfig) Option {
+	return func(el *EventLoop) {
+		el.poolConfig = &config
+	}
+}
+
+// workerPool runs jobs on a fixed number of goroutines
+type workerPool struct {
+	config   PoolConfig
+	jobs     chan func()
+	quit     chan struct{}
+	mu       sync.Mutex
+	overflow []func()
+	stopOnce sync.Once
+
+	active    atomic.Int64
+	submitted atomic.Uint64
+	completed atomic.Uint64
+	dropped   atomic.Uint64
+	saturated atomic.Uint64
+}
+
+// newWorkerPool creates a worker pool and starts its workers
+func newWorkerPool(config PoolConfig) *workerPool {
+	config.Workers = max(config.Workers, 1)
+	config.QueueDepth = max(config.QueueDepth, 0)
+
+	p := &workerPool{
+		config: config,
+		jobs:   make(chan func(), config.QueueDepth),
+		quit:   make(chan struct{}),
+	}
+	for i := 0; i < config.Workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// submit hands a job to the pool, reporting false if it was dropped
+func (p *workerPool) submit(job func()) bool {
+	return p.submitWith(job, p.config.WhenFull)
+}
+
+// submitNoWait hands a job to the pool without blocking, spilling it if the queue
+// is full unless the pool drops events when full
+func (p *workerPool) submitNoWait(job func()) bool {
+	policy := p.config.WhenFull
+	if policy == PoolBlock {
+		policy = PoolSpill
+	}
+	return p.submitWith(job, policy)
+}
+
+// submitWith hands a job to the pool, applying policy if the queue is full
+func (p *workerPool) submitWith(job func(), policy PoolFullPolicy) bool {
+	p.submitted.Add(1)
+
+	// Once events spill, later ones queue behind them to keep the order they were fired in
+	p.mu.Lock()
+	if len(p.overflow) > 0 {
+		p.overflow = append(p.overflow, job)
+		p.mu.Unlock()
+		return true
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+	}
+
+	p.saturated.Add(1)
+	switch policy {
+	case PoolDrop:
+		p.dropped.Add(1)
+		return false
+
+	case PoolSpill:
+		p.mu.Lock()
+		p.overflow = append(p.overflow, job)
+		p.mu.Unlock()
+		return true
+
+	default:
+		select {
+		case p.jobs <- job:
+			return true
+		case <-p.quit:
+			p.dropped.Add(1)
+			return false
+		}
+	}
+}
+
+// work runs jobs until the pool is stopped
+func (p *workerPool) work() {
+	for {
+		job, ok := p.next()
+		if !ok {
+			return
+		}
+
+		p.active.Add(1)
+		job()
+		p.active.Add(-1)
+		p.completed.Add(1)
+	}
+}
+
+// next returns the oldest waiting job, blocking until one is available or the pool stops
+func (p *workerPool) next() (func(), bool) {
+	// Jobs in the queue are always older than spilled ones
+	select {
+	case job := <-p.jobs:
+		return job, true
+	case <-p.quit:
+		return nil, false
+	default:
+	}
+
+	p.mu.Lock()
+	if len(p.overflow) > 0 {
+		job := p.overflow[0]
+		p.overflow[0] = nil
+		p.overflow = p.overflow[1:]
+		p.mu.Unlock()
+		return job, true
+	}
+	p.mu.Unlock()
+
+	select {
+	case job := <-p.jobs:
+		return job, true
+	case <-p.quit:
+		return nil, false
+	}
+}
+
+// stop makes the workers exit once their current job is done, abandoning waiting jobs
+func (p *workerPool) stop() {
+	p.stopOnce.Do(func() {
+		close(p.quit)
+	})
+}
+
+// stats returns a snapshot of the pool metrics
+func (p *workerPool) stats() PoolStats {
+	p.mu.Lock()
+	spilled := len(p.overflow)
+	p.mu.Unlock()
+
+	return PoolStats{
+		Workers:    p.config.Workers,
+		QueueDepth: p.config.QueueDepth,
+		Queued:     len(p.jobs),
+		Spilled:    spilled,
+		Active:     int(p.active.Load()),
+		Submitted:  p.submitted.Load(),
+		Completed:  p.completed.Load(),
+		Dropped:    p.dropped.Load(),
+		Saturated:  p.saturated.Load(),
+	}
+}
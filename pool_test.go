@@ -0,0 +1,168 @@
+package eventgoround
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolDrop(t *testing.T) {
+	pool := newWorkerPool(PoolConfig{Workers: 1, QueueDepth: 1, WhenFull: PoolDrop})
+	defer pool.stop()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	// One job fits in the queue, the next one is dropped
+	if !pool.submit(func() {}) {
+		t.Error("Expected the job to fit in the queue")
+	}
+	if pool.submit(func() {}) {
+		t.Error("Expected the job to be dropped while the queue is full")
+	}
+
+	stats := pool.stats()
+	if stats.Dropped != 1 || stats.Saturated != 1 || stats.Queued != 1 || stats.Active != 1 {
+		t.Errorf("Unexpected stats while saturated: %+v", stats)
+	}
+	close(release)
+}
+
+func TestWorkerPoolSpill(t *testing.T) {
+	pool := newWorkerPool(PoolConfig{Workers: 1, QueueDepth: 2, WhenFull: PoolSpill})
+	defer pool.stop()
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+
+	release := make(chan struct{})
+	wg.Add(1)
+	pool.submit(func() {
+		<-release
+		wg.Done()
+	})
+
+	// Everything beyond the queue spills but still runs in submission order
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		pool.submit(func() {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			wg.Done()
+		})
+	}
+
+	if stats := pool.stats(); stats.Spilled == 0 || stats.Dropped != 0 {
+		t.Errorf("Expected jobs to spill without being dropped: %+v", stats)
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i, v := range order {
+		if v != i {
+			t.Fatalf("Expected jobs to run in submission order, got %v", order)
+		}
+	}
+}
+
+// TestWorkerPoolCatchUp - Scenario 16: A catch-up burst runs on a bounded number of goroutines
+func TestWorkerPoolCatchUp(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	peak := &peakTracker{}
+	registry.RegisterHandler("burst", peak.wrap(time.Millisecond, tracker.track("burst", nil, 0)))
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil, WithWorkerPool(PoolConfig{Workers: 4, QueueDepth: 16}))
+	loop.Start()
+	defer loop.Stop()
+
+	const burst = 200
+	now := time.Now().Unix()
+	tracker.expectCount(burst)
+	for i := 0; i < burst; i++ {
+		loop.ScheduleEvent(now-100, int64(i%50), "burst", i)
+	}
+
+	if !tracker.waitWithTimeout(5 * time.Second) {
+		t.Fatalf("Timeout waiting for burst. Got %d executions, expected %d", tracker.count(), burst)
+	}
+
+	if p := peak.peak.Load(); p > 4 {
+		t.Errorf("Expected at most 4 handlers to run at once, saw %d", p)
+	}
+
+	stats := loop.PoolStats()
+	if stats.Submitted != burst || stats.Dropped != 0 {
+		t.Errorf("Unexpected pool stats after burst: %+v", stats)
+	}
+
+	t.Logf("Successfully ran %d events on 4 workers (saturated %d times)", burst, stats.Saturated)
+}
+
+// TestWorkerPoolFullReschedule - Scenario 32: Firing a rescheduled event into a full pool does not block handlers that schedule events
+func TestWorkerPoolFullReschedule(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("target", tracker.track("target", nil, 0))
+
+	var loop *EventLoop
+	started := make(chan struct{})
+	gate := make(chan struct{})
+	scheduled := make(chan error, 1)
+	registry.RegisterHandler("busy", func(data any) {
+		close(started)
+		<-gate
+		_, err := loop.ScheduleEvent(time.Now().Unix()+3600, 0, "target", "later")
+		scheduled <- err
+	})
+
+	loop = NewEventLoop(10*time.Millisecond, registry, nil, WithWorkerPool(PoolConfig{Workers: 1, QueueDepth: 0}))
+	loop.Start()
+	defer loop.Stop()
+
+	now := time.Now().Unix()
+	handle, err := loop.ScheduleEvent(now, 3600, "target", "now")
+	if err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	loop.ScheduleEvent(now, 0, "busy", nil)
+	<-started
+
+	// The only worker is busy, so firing the shortened event waits for it
+	tracker.expectCount(1)
+	shortened := make(chan error, 1)
+	go func() { shortened <- loop.Shorten(handle.ID(), 3600) }()
+	time.Sleep(50 * time.Millisecond)
+	close(gate)
+
+	select {
+	case err := <-scheduled:
+		if err != nil {
+			t.Errorf("Scheduling from the busy handler failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Deadlock: the busy handler could not schedule while a shortened event waited for the pool")
+	}
+	select {
+	case err := <-shortened:
+		if err != nil {
+			t.Errorf("Shorten failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shorten did not return once the worker was free")
+	}
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatal("Timeout waiting for the shortened event")
+	}
+
+	t.Log("Successfully fired a shortened event into a full worker pool")
+}
@@ -0,0 +1,72 @@
+package eventgoround
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultDrainTimeout is how long Run waits for running handlers once its context is cancelled
+const defaultDrainTimeout = 30 * time.Second
+
+// WithDrainTimeout sets how long Run waits for running handlers after its context is cancelled
+// or a fatal error occurred, 30 seconds by default
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(el *EventLoop) {
+		el.drainTimeout = timeout
+	}
+}
+
+// Run runs the event loop in the calling goroutine until ctx is cancelled, the loop is stopped
+// or a fatal error occurs, then shuts it down gracefully as Shutdown does
+// It returns nil after a clean shutdown, the fatal error, such as a failure to write the event log,
+// that stopped the loop, or an error if running handlers were abandoned after the drain timeout
+func (el *EventLoop) Run(ctx context.Context) error {
+	if _, err := el.transition("run", StateRunning, StateCreated); err != nil {
+		return err
+	}
+	el.logInfo("event loop started", "tickInterval", el.tickInterval, "wakeMode", el.wakeMode, "resolution", el.resolution.unit())
+
+	shutdown := make(chan error, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			el.logInfo("event loop context done - shutting down", "cause", context.Cause(ctx))
+		case <-el.fatal:
+			el.logError("event loop failed - shutting down", "error", el.fatalErr)
+		case <-el.stopChan:
+			shutdown <- nil
+			return
+		}
+
+		drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), el.drainTimeout)
+		defer cancel()
+		_, err := el.Shutdown(drainCtx)
+		if errors.Is(err, ErrLoopStopped) {
+			err = nil
+		}
+		shutdown <- err
+	}()
+
+	el.run()
+	err := <-shutdown
+
+	select {
+	case <-el.fatal:
+		return el.fatalErr
+	default:
+	}
+	if err != nil {
+		return fmt.Errorf("failed to drain event loop: %w", err)
+	}
+	return nil
+}
+
+// fail records the first fatal error and makes Run shut the loop down
+func (el *EventLoop) fail(err error) {
+	el.fatalOnce.Do(func() {
+		el.fatalErr = err
+		close(el.fatal)
+	})
+}
@@ -0,0 +1,86 @@
+package eventgoround
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRun - Scenario 24: Run blocks until its context is cancelled and drains running handlers
+func TestRun(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+
+	started := make(chan struct{})
+	trackSlow := tracker.track("slow", nil, 0)
+	registry.RegisterHandler("slow", func(payload any) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		trackSlow(payload)
+	})
+
+	loop := NewEventLoop(10*time.Millisecond, registry, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- loop.Run(ctx)
+	}()
+
+	tracker.expectCount(1)
+	if _, err := loop.ScheduleEvent(time.Now().Unix(), 0, "slow", nil); err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Run to return nil after a clean shutdown, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for Run to return")
+	}
+
+	// Run only returns once the running handler has finished
+	if tracker.count() != 1 {
+		t.Error("Expected Run to wait for the running handler")
+	}
+	if state := loop.State(); state != StateStopped {
+		t.Errorf("Expected the loop to be stopped, got %s", state)
+	}
+	if err := loop.Run(context.Background()); !errors.Is(err, ErrLoopStopped) {
+		t.Errorf("Expected running a stopped loop to fail with ErrLoopStopped, got %v", err)
+	}
+
+	t.Log("Successfully ran the event loop until its context was cancelled")
+}
+
+func TestRunFatalError(t *testing.T) {
+	registry := newMockRegistry()
+	registry.RegisterHandler("noop", func(any) {})
+
+	loop := NewEventLoop(10*time.Millisecond, registry, nil, WithPersistence(filepath.Join(t.TempDir(), "events.wal")))
+	done := make(chan error, 1)
+	go func() {
+		done <- loop.Run(context.Background())
+	}()
+
+	// Losing the event log makes every further write fail
+	loop.eventLog.file.Close()
+	if _, err := loop.ScheduleAfter(time.Hour, "noop", nil); err == nil {
+		t.Error("Expected scheduling to fail without an event log")
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, os.ErrClosed) {
+			t.Errorf("Expected Run to return the persistence failure, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for Run to stop on a fatal error")
+	}
+}
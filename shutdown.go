@@ -0,0 +1,86 @@
+package eventgoround
+
+import (
+	"context"
+)
+
+// ShutdownPolicy decides what Shutdown does with events that are due but have not fired yet
+type ShutdownPolicy int
+
+const (
+	// ShutdownKeepDue leaves due events in storage, where the event log keeps them for the next start
+	ShutdownKeepDue ShutdownPolicy = iota
+	// ShutdownFireDue fires due events and waits for them along with the handlers already running
+	ShutdownFireDue
+)
+
+// WithShutdownPolicy sets what Shutdown does with due events, ShutdownKeepDue by default
+func WithShutdownPolicy(policy ShutdownPolicy) Option {
+	return func(el *EventLoop) {
+		el.onShutdown = policy
+	}
+}
+
+// ShutdownSummary reports what happened to the loop's events during Shutdown
+type ShutdownSummary struct {
+	Fired     int // Due events fired while shutting down
+	Completed int // Handlers that finished while shutting down
+	Abandoned int // Handlers still running or queued when the context ended
+	Pending   int // Events left in storage, kept in the event log if persistence is enabled
+}
+
+// Shutdown stops the event loop gracefully
+// It stops accepting new events, applies the shutdown policy to due events and waits for running
+// handlers until ctx ends before cancelling their contexts and closing the event log and log file.
+// The returned error is the context's error if handlers had to be abandoned, or a *StateError if
+// the loop is already stopping
+func (el *EventLoop) Shutdown(ctx context.Context) (ShutdownSummary, error) {
+	var summary ShutdownSummary
+	previous, err := el.transition("shut down", StateStopping, StateCreated, StateRunning, StatePaused)
+	if err != nil {
+		return summary, err
+	}
+	el.logInfo("event loop shutting down", "policy", el.onShutdown)
+
+	// Wait for the run loop to return so nothing else takes events from storage
+	close(el.stopChan)
+	if previous != StateCreated {
+		select {
+		case <-el.runDone:
+		case <-ctx.Done():
+		}
+	}
+
+	running := el.inflight.Load()
+	if ctx.Err() == nil {
+		el.drainEvents()
+		if el.onShutdown == ShutdownFireDue {
+			summary.Fired = el.processTimestamp(el.now(), nil)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		el.handlers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	summary.Abandoned = int(el.inflight.Load())
+	summary.Completed = int(running) + summary.Fired - summary.Abandoned
+
+	// Events still on their way to storage count as pending, the event log already holds them
+	el.pendingMu.Lock()
+	summary.Pending = el.storage.Len() + len(el.staged)
+	el.pendingMu.Unlock()
+
+	el.logInfo("event loop shut down", "fired", summary.Fired, "completed", summary.Completed, "abandoned", summary.Abandoned, "pending", summary.Pending)
+	el.release()
+	el.transition("shut down", StateStopped, StateStopping)
+	return summary, err
+}
@@ -0,0 +1,95 @@
+package eventgoround
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestShutdown - Scenario 22: Shutdown waits for running handlers and fires or keeps due events
+func TestShutdown(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name      string
+		policy    ShutdownPolicy
+		timeout   time.Duration
+		fired     int
+		completed int
+		abandoned int
+		pending   int
+	}{
+		{name: "fire due", policy: ShutdownFireDue, timeout: 2 * time.Second, fired: 1, completed: 2, pending: 1},
+		{name: "keep due", policy: ShutdownKeepDue, timeout: 2 * time.Second, completed: 1, pending: 2},
+		{name: "deadline", policy: ShutdownKeepDue, timeout: 50 * time.Millisecond, abandoned: 1, pending: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := newMockRegistry()
+			tracker := newExecutionTracker()
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			trackSlow := tracker.track("slow", nil, 0)
+			registry.RegisterHandler("slow", func(payload any) {
+				close(started)
+				<-release
+				if tc.abandoned == 0 {
+					trackSlow(payload)
+				}
+			})
+			registry.RegisterHandler("due", tracker.track("due", nil, 0))
+			registry.RegisterHandler("later", tracker.track("later", nil, 0))
+
+			clock := NewFakeClock(start)
+			loop := NewEventLoop(time.Second, registry, nil, WithClock(clock), WithShutdownPolicy(tc.policy))
+			loop.Start()
+
+			loop.ScheduleAfter(0, "slow", nil)
+			clock.BlockUntil(1)
+			clock.Advance(time.Second)
+			<-started
+
+			// Due but not yet fired when Shutdown is called, and one for later
+			loop.ScheduleAfter(0, "due", nil)
+			loop.ScheduleAfter(time.Hour, "later", nil)
+
+			if tc.abandoned == 0 {
+				time.AfterFunc(50*time.Millisecond, func() { close(release) })
+			} else {
+				defer close(release)
+			}
+
+			tracker.expectCount(tc.completed)
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+			summary, err := loop.Shutdown(ctx)
+
+			if tc.abandoned > 0 {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("Expected Shutdown to report the deadline, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Shutdown failed: %v", err)
+			}
+			if !tracker.waitWithTimeout(time.Second) {
+				t.Fatalf("Expected %d handlers to finish during Shutdown, got %d", tc.completed, tracker.count())
+			}
+
+			expected := ShutdownSummary{Fired: tc.fired, Completed: tc.completed, Abandoned: tc.abandoned, Pending: tc.pending}
+			if summary != expected {
+				t.Errorf("Expected summary %+v, got %+v", expected, summary)
+			}
+
+			if _, err := loop.ScheduleAfter(0, "due", nil); !errors.Is(err, ErrLoopStopped) {
+				t.Errorf("Expected scheduling after Shutdown to fail with ErrLoopStopped, got %v", err)
+			}
+			if _, err := loop.Shutdown(context.Background()); !errors.Is(err, ErrLoopStopped) {
+				t.Errorf("Expected a second Shutdown to fail with ErrLoopStopped, got %v", err)
+			}
+			loop.Stop()
+		})
+	}
+
+	t.Log("Successfully shut down the event loop gracefully")
+}
@@ -0,0 +1,169 @@
+package eventgoround
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// storedEvent is a pending event together with its position in the heap
+type storedEvent struct {
+	event Event
+	due   int64  // Timestamp + Duration
+	seq   uint64 // Insertion order, keeps events with the same due time in schedule order
+	index int    // Position in the heap, maintained by eventHeap
+}
+
+// eventHeap is a min-heap of pending events ordered by due time and then insertion order
+type eventHeap []*storedEvent
+
+func (h eventHeap) Len() int { return len(h) }
+
+func (h eventHeap) Less(i, j int) bool {
+	if h[i].due != h[j].due {
+		return h[i].due < h[j].due
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h eventHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *eventHeap) Push(x any) {
+	item := x.(*storedEvent)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *eventHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// Storage holds pending events for an EventLoop
+// Events are due at Timestamp + Duration. Implementations must be safe for concurrent use
+// and must not call back into the EventLoop. Events returned by an implementation that does not
+// keep them in memory have their handler looked up from the registry again before they fire
+type Storage interface {
+	// Add stores a pending event
+	Add(event Event) error
+	// PopDue removes and returns all events due at or before upTo, earliest first.
+	// Events due at the same time must be returned in the order they were added
+	PopDue(upTo int64) ([]Event, error)
+	// NextDue returns the time the earliest pending event is due, reporting false if there is none
+	NextDue() (int64, bool)
+	// Remove removes the event with the given ID and returns it, reporting false if it is not stored
+	Remove(id uint64) (Event, bool, error)
+	// Len returns the number of pending events
+	Len() int
+	// Iterate calls fn for every pending event in no particular order until fn returns false
+	Iterate(fn func(event Event) bool)
+}
+
+// WithStorage replaces the default in-memory storage of pending events
+func WithStorage(storage Storage) Option {
+	return func(el *EventLoop) {
+		el.storage = storage
+	}
+}
+
+// MemoryStorage is the default thread-safe Storage, keeping events in a min-heap ordered by due time
+// Peeking the next due time is O(1), adding, removing and popping events is O(log n)
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	heap  eventHeap
+	index map[uint64]*storedEvent // Map of event ID to its heap entry
+	seq   uint64
+}
+
+// NewMemoryStorage creates a new in-memory event storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		index: make(map[uint64]*storedEvent),
+	}
+}
+
+// Add adds an event to the storage, due at its timestamp + duration
+func (es *MemoryStorage) Add(event Event) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	es.seq++
+	item := &storedEvent{
+		event: event,
+		due:   event.Timestamp + event.Duration,
+		seq:   es.seq,
+	}
+	heap.Push(&es.heap, item)
+	es.index[event.ID] = item
+	return nil
+}
+
+// Remove removes the event with the given ID and returns it, reporting whether it was found
+func (es *MemoryStorage) Remove(id uint64) (Event, bool, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	item, ok := es.index[id]
+	if !ok {
+		return Event{}, false, nil
+	}
+	delete(es.index, id)
+	heap.Remove(&es.heap, item.index)
+	return item.event, true, nil
+}
+
+// PopDue removes and returns all events due at or before the given time in the order they are due
+func (es *MemoryStorage) PopDue(upTo int64) ([]Event, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	var events []Event
+	for len(es.heap) > 0 && es.heap[0].due <= upTo {
+		item := heap.Pop(&es.heap).(*storedEvent)
+		delete(es.index, item.event.ID)
+		events = append(events, item.event)
+	}
+	return events, nil
+}
+
+// NextDue returns the time the earliest pending event is due, reporting false if storage is empty
+func (es *MemoryStorage) NextDue() (int64, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	if len(es.heap) == 0 {
+		return 0, false
+	}
+	return es.heap[0].due, true
+}
+
+// Len returns the number of pending events
+func (es *MemoryStorage) Len() int {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	return len(es.heap)
+}
+
+// Iterate calls fn for every pending event in no particular order until fn returns false
+// fn must not modify the storage
+func (es *MemoryStorage) Iterate(fn func(event Event) bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	for _, item := range es.heap {
+		if !fn(item.event) {
+			return
+		}
+	}
+}
+
+// Ensure MemoryStorage implements Storage
+var _ Storage = (*MemoryStorage)(nil)
@@ -0,0 +1,263 @@
+package eventgoround
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorageOrdering(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	// Insert out of order, with two events sharing a due time
+	storage.Add(Event{ID: 1, Timestamp: 100, Duration: 30})
+	storage.Add(Event{ID: 2, Timestamp: 110, Duration: 0})
+	storage.Add(Event{ID: 3, Timestamp: 90, Duration: 0})
+	storage.Add(Event{ID: 4, Timestamp: 120, Duration: 10})
+
+	if due, ok := storage.NextDue(); !ok || due != 90 {
+		t.Fatalf("Expected next due time 90, got %d (ok=%v)", due, ok)
+	}
+	if events, _ := storage.PopDue(89); len(events) != 0 {
+		t.Errorf("Expected no events due before 90, got %d", len(events))
+	}
+
+	events, err := storage.PopDue(130)
+	if err != nil {
+		t.Fatalf("PopDue failed: %v", err)
+	}
+	expected := []uint64{3, 2, 1, 4}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d due events, got %d", len(expected), len(events))
+	}
+	for i, id := range expected {
+		if events[i].ID != id {
+			t.Errorf("Position %d: expected event %d, got %d", i, id, events[i].ID)
+		}
+	}
+
+	if storage.Len() != 0 {
+		t.Errorf("Expected storage to be empty, %d events left", storage.Len())
+	}
+	if _, ok := storage.NextDue(); ok {
+		t.Error("Expected no next due time for empty storage")
+	}
+}
+
+func TestMemoryStorageRemove(t *testing.T) {
+	storage := NewMemoryStorage()
+	for i := 1; i <= 5; i++ {
+		storage.Add(Event{ID: uint64(i), Timestamp: int64(i * 10)})
+	}
+
+	removed, ok, err := storage.Remove(1)
+	if err != nil || !ok || removed.ID != 1 {
+		t.Fatalf("Expected to remove event 1, got %d (ok=%v)", removed.ID, ok)
+	}
+	if _, ok, _ := storage.Remove(1); ok {
+		t.Error("Removing an event twice should fail")
+	}
+	storage.Remove(4)
+
+	if due, _ := storage.NextDue(); due != 20 {
+		t.Errorf("Expected next due time 20 after removing the head, got %d", due)
+	}
+
+	seen := make(map[uint64]bool)
+	storage.Iterate(func(event Event) bool {
+		seen[event.ID] = true
+		return true
+	})
+	if len(seen) != 3 || !seen[2] || !seen[3] || !seen[5] {
+		t.Errorf("Expected to iterate over events 2, 3 and 5, got %v", seen)
+	}
+
+	events, _ := storage.PopDue(100)
+	expected := []uint64{2, 3, 5}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+	for i, id := range expected {
+		if events[i].ID != id {
+			t.Errorf("Position %d: expected event %d, got %d", i, id, events[i].ID)
+		}
+	}
+}
+
+// serialisingStorage behaves like a database backed storage by dropping everything that
+// does not survive serialisation, such as the resolved handler
+type serialisingStorage struct {
+	*MemoryStorage
+}
+
+func (s serialisingStorage) Add(event Event) error {
+	return s.MemoryStorage.Add(Event{
+		ID:        event.ID,
+		Timestamp: event.Timestamp,
+		Duration:  event.Duration,
+		Payload:   event.Payload,
+		Handler:   event.Handler,
+	})
+}
+
+// TestCustomStorage - Scenario 15: The loop runs on a caller supplied storage
+func TestCustomStorage(t *testing.T) {
+	registry := newMockRegistry()
+	tracker := newExecutionTracker()
+	registry.RegisterHandler("stored", tracker.track("stored", nil, 0))
+
+	// The storage already holds an event from a previous run
+	storage := serialisingStorage{NewMemoryStorage()}
+	storage.Add(Event{ID: 41, Timestamp: time.Now().Unix(), Handler: "stored", Payload: "restored"})
+
+	loop := NewEventLoop(50*time.Millisecond, registry, nil, WithStorage(storage))
+	loop.Start()
+	defer loop.Stop()
+
+	tracker.expectCount(2)
+	handle, err := loop.ScheduleEvent(time.Now().Unix(), 0, "stored", "new")
+	if err != nil {
+		t.Fatalf("Failed to schedule event: %v", err)
+	}
+	if handle.ID() <= 41 {
+		t.Errorf("Expected new IDs to continue after the stored ones, got %d", handle.ID())
+	}
+
+	if !tracker.waitWithTimeout(2 * time.Second) {
+		t.Fatalf("Timeout waiting for events from custom storage. Got %d executions, expected 2", tracker.count())
+	}
+	if storage.Len() != 0 {
+		t.Errorf("Expected storage to be empty, %d events left", storage.Len())
+	}
+
+	t.Log("Successfully fired events from a custom storage")
+}
+
+// benchmarkPendingEvents is the number of timers held in storage while benchmarking
+const benchmarkPendingEvents = 1_000_000
+
+// newBenchmarkStorage returns a storage holding benchmarkPendingEvents events spread over a day
+func newBenchmarkStorage(b *testing.B) *MemoryStorage {
+	b.Helper()
+	rng := rand.New(rand.NewSource(1))
+	storage := NewMemoryStorage()
+	for i := 0; i < benchmarkPendingEvents; i++ {
+		storage.Add(Event{ID: uint64(i + 1), Timestamp: 1_000_000 + rng.Int63n(86400)})
+	}
+	return storage
+}
+
+func BenchmarkMemoryStorageAdd(b *testing.B) {
+	storage := newBenchmarkStorage(b)
+	rng := rand.New(rand.NewSource(2))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		storage.Add(Event{ID: uint64(benchmarkPendingEvents + i + 1), Timestamp: 1_000_000 + rng.Int63n(86400)})
+	}
+}
+
+func BenchmarkMemoryStorageNextDue(b *testing.B) {
+	storage := newBenchmarkStorage(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		storage.NextDue()
+	}
+}
+
+func BenchmarkMemoryStoragePopDue(b *testing.B) {
+	storage := newBenchmarkStorage(b)
+	b.ResetTimer()
+
+	// Pop the earliest event and replace it so storage stays at the same size
+	for i := 0; i < b.N; i++ {
+		due, _ := storage.NextDue()
+		events, _ := storage.PopDue(due)
+		for _, event := range events {
+			event.Timestamp += 86400
+			storage.Add(event)
+		}
+	}
+}
+
+func BenchmarkMemoryStorageRemove(b *testing.B) {
+	storage := newBenchmarkStorage(b)
+	rng := rand.New(rand.NewSource(3))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		id := uint64(rng.Int63n(benchmarkPendingEvents) + 1)
+		if event, ok, _ := storage.Remove(id); ok {
+			storage.Add(event)
+		}
+	}
+}
+
+// mapEventStorage is the map of due times with a bubble sort that MemoryStorage replaced, kept to benchmark against
+type mapEventStorage struct {
+	events map[int64][]Event
+}
+
+func (es *mapEventStorage) add(event Event) {
+	timestamp := event.Duration + event.Timestamp
+	es.events[timestamp] = append(es.events[timestamp], event)
+}
+
+func (es *mapEventStorage) getTimestampsUpTo(currentTime int64) []int64 {
+	timestamps := make([]int64, 0)
+	for ts := range es.events {
+		if ts <= currentTime {
+			timestamps = append(timestamps, ts)
+		}
+	}
+
+	for i := 0; i < len(timestamps)-1; i++ {
+		for j := i + 1; j < len(timestamps); j++ {
+			if timestamps[i] > timestamps[j] {
+				timestamps[i], timestamps[j] = timestamps[j], timestamps[i]
+			}
+		}
+	}
+	return timestamps
+}
+
+func (es *mapEventStorage) hasPastEvents(currentTime int64) bool {
+	for ts := range es.events {
+		if ts < currentTime {
+			return true
+		}
+	}
+	return false
+}
+
+// newBenchmarkMapStorage returns a map storage holding the same events as newBenchmarkStorage
+func newBenchmarkMapStorage(b *testing.B) *mapEventStorage {
+	b.Helper()
+	rng := rand.New(rand.NewSource(1))
+	storage := &mapEventStorage{events: make(map[int64][]Event)}
+	for i := 0; i < benchmarkPendingEvents; i++ {
+		storage.add(Event{ID: uint64(i + 1), Timestamp: 1_000_000 + rng.Int63n(86400)})
+	}
+	return storage
+}
+
+// Every tick scanned the whole map when nothing was overdue, compare with BenchmarkMemoryStorageNextDue
+func BenchmarkMapStorageHasPastEvents(b *testing.B) {
+	storage := newBenchmarkMapStorage(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		storage.hasPastEvents(1_000_000)
+	}
+}
+
+// Catching up after a day down bubble sorted all 86400 due times before firing the first event
+func BenchmarkMapStorageGetTimestampsUpTo(b *testing.B) {
+	storage := newBenchmarkMapStorage(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		storage.getTimestampsUpTo(1_000_000 + 86400)
+	}
+}